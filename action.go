@@ -1,16 +1,18 @@
 package carapace
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"regexp"
 	"runtime"
-	"strings"
+	"sort"
 	"time"
 
 	"github.com/rsteube/carapace/internal/cache"
 	"github.com/rsteube/carapace/internal/common"
 	pkgcache "github.com/rsteube/carapace/pkg/cache"
+	"github.com/rsteube/carapace/pkg/match"
 )
 
 // Action indicates how to complete a flag or positional argument
@@ -19,6 +21,11 @@ type Action struct {
 	callback  CompletionCallback
 	nospace   bool
 	skipcache bool
+	keeporder bool
+	matcher   match.Matcher
+	matchdesc bool
+	invalid   string // reason the Action was created with invalid arguments (reported by Test)
+	segment   string // prefix of the values already completed by ActionMultiParts (skipped by the matcher)
 }
 
 // ActionMap maps Actions to an identifier
@@ -27,39 +34,95 @@ type ActionMap map[string]Action
 // CompletionCallback is executed during completion of associated flag or positional argument
 type CompletionCallback func(c Context) Action
 
-// Context provides information during completion
-type Context struct {
-	// CallbackValue contains the (partial) value (or part of it during an ActionMultiParts) currently being completed
-	CallbackValue string
-	// Args contains the positional arguments of current (sub)command (exclusive the one currently being completed)
-	Args []string
-	// Parts contains the splitted CallbackValue during an ActionMultiParts (exclusive the part currently being completed)
-	Parts []string
-}
-
 // Cache cashes values of a CompletionCallback for given duration and keys
 func (a Action) Cache(timeout time.Duration, keys ...pkgcache.Key) Action {
+	_, file, line, _ := runtime.Caller(1) // generate uid from wherever Cache() was called
+	return a.cache(file, line, timeout, false, keys...)
+}
+
+// CacheStale is like Cache but returns expired values immediately while they are refreshed in a detached background process (stale-while-revalidate)
+//   carapace.ActionCallback(func(c carapace.Context) carapace.Action {
+//       return aws.ActionRegions() // slow remote listing
+//   }).CacheStale(24 * time.Hour)
+func (a Action) CacheStale(timeout time.Duration, keys ...pkgcache.Key) Action {
+	_, file, line, _ := runtime.Caller(1) // generate uid from wherever CacheStale() was called
+	return a.cache(file, line, timeout, true, keys...)
+}
+
+func (a Action) cache(file string, line int, timeout time.Duration, stale bool, keys ...pkgcache.Key) Action {
 	// TODO static actions are using callback now as well (for performance) - probably best to add a `static` bool to Action for this and check that here
 	if a.callback != nil { // only relevant for callback actions
 		cachedCallback := a.callback
-		_, file, line, _ := runtime.Caller(1) // generate uid from wherever Cache() was called
 		a.callback = func(c Context) Action {
-			if cacheFile, err := cache.File(file, line, keys...); err == nil {
-				if rawValues, err := cache.Load(cacheFile, timeout); err == nil {
-					return actionRawValues(rawValues...)
+			backend, err := cacheBackend()
+			if err != nil {
+				return cachedCallback(c)
+			}
+			resolvedKeys, err := cache.Keys(keys...)
+			if err != nil {
+				return cachedCallback(c)
+			}
+			id := cache.ID(file, line, resolvedKeys)
+
+			refresh := func() Action {
+				if unlock, err := cache.Lock(c, backend, id); err == nil {
+					defer unlock()
+					if entry, err := cache.Load(backend, id, timeout); err == nil {
+						return actionCacheEntry(entry) // refreshed by another process in the meantime
+					}
 				}
 				invokedAction := (Action{callback: cachedCallback}).Invoke(c)
 				if !invokedAction.skipcache {
-					_ = cache.Write(cacheFile, invokedAction.rawValues)
+					_ = cache.Write(backend, id, cache.Entry{
+						RawValues:         invokedAction.rawValues,
+						Nospace:           invokedAction.nospace,
+						KeepOrder:         invokedAction.keeporder,
+						MatchDescriptions: invokedAction.matchdesc,
+						Segment:           invokedAction.segment,
+						Caller:            fmt.Sprintf("%v:%v", file, line),
+						Keys:              resolvedKeys,
+						Timeout:           timeout,
+						Stale:             stale,
+					})
+					_ = cache.GC(backend, cacheMaxSize()) // opportunistic cleanup (throttled)
 				}
 				return invokedAction.ToA()
 			}
-			return cachedCallback(c)
+
+			if cache.IsRefresh(id) {
+				return refresh() // detached process refreshing this entry
+			}
+			if entry, err := cache.Load(backend, id, timeout); err == nil {
+				return actionCacheEntry(entry)
+			}
+			if stale {
+				if entry, err := cache.Load(backend, id, 0); err == nil {
+					refreshInBackground(id, func() { refresh() })
+					return actionCacheEntry(entry)
+				}
+			}
+			return refresh()
 		}
 	}
 	return a
 }
 
+func actionCacheEntry(e cache.Entry) Action {
+	return actionRawValues(e.RawValues...).noSpace(e.Nospace).keepOrder(e.KeepOrder).match(nil, e.MatchDescriptions).withSegment(e.Segment)
+}
+
+// refreshInBackground repeats the completion in a detached process (or a goroutine when not invoked for completion)
+func refreshInBackground(id string, refresh func()) {
+	switch {
+	case cache.IsRefreshProcess():
+		// already within a detached process so don't spawn further ones
+	case IsCallback(), len(os.Args) > 1 && (os.Args[1] == "__complete" || os.Args[1] == "__completeNoDesc"):
+		_ = cache.Detach(id) // process exits right after completion
+	default:
+		go refresh()
+	}
+}
+
 // Invoke executes the callback of an action if it exists (supports nesting)
 func (a Action) Invoke(c Context) InvokedAction {
 	if c.Args == nil {
@@ -68,6 +131,9 @@ func (a Action) Invoke(c Context) InvokedAction {
 	if c.Parts == nil {
 		c.Parts = []string{}
 	}
+	if c.Context == nil {
+		c.Context = context.Background()
+	}
 	return InvokedAction{a.nestedAction(c, 10)}
 }
 
@@ -76,7 +142,7 @@ func (a Action) nestedAction(c Context, maxDepth int) Action {
 		return ActionMessage("maximum recursion depth exceeded")
 	}
 	if a.rawValues == nil && a.callback != nil {
-		return a.callback(c).nestedAction(c, maxDepth-1).noSpace(a.nospace).skipCache(a.skipcache)
+		return a.callback(c).nestedAction(c, maxDepth-1).noSpace(a.nospace).skipCache(a.skipcache).keepOrder(a.keeporder).match(a.matcher, a.matchdesc)
 	}
 	return a
 }
@@ -86,47 +152,146 @@ func (a Action) NoSpace() Action {
 	return a.noSpace(true)
 }
 
-// Chdir changes the current working directory to the named directory during invocation.
+// Chdir changes the working directory (Context.Dir) to the named directory during invocation.
+// Relative paths are resolved against the current one.
 func (a Action) Chdir(dir string) Action {
 	return ActionCallback(func(c Context) Action {
 		if dir == "" || dir == "." {
 			return a // do nothing on current dir
 		}
 
-		if strings.HasPrefix(dir, "~") {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				return ActionMessage(err.Error())
-			}
-			dir = strings.Replace(dir, "~", home, 1)
+		abs, err := expandPath(c, dir)
+		if err != nil {
+			return ActionMessage(err.Error())
 		}
+		abs = c.abs(abs)
 
-		file, err := os.Stat(dir)
+		file, err := os.Stat(abs)
 		if err != nil {
+			if pathErr, ok := err.(*os.PathError); ok {
+				pathErr.Path = dir // report the path as given
+			}
 			return ActionMessage(err.Error())
 		}
 		if !file.IsDir() {
 			return ActionMessage(fmt.Sprintf("%v is not a directory", dir))
 		}
 
-		current, err := os.Getwd()
-		if err != nil {
-			return ActionMessage(err.Error())
+		c.Dir = abs
+		return a.Invoke(c).ToA()
+	}).withInvalid(a)
+}
+
+// Style sets the style for all values
+//   carapace.ActionValues("one", "two").Style(style.Red)
+func (a Action) Style(style string) Action {
+	return a.StyleF(func(s string) string {
+		return style
+	})
+}
+
+// StyleF sets the style for all values using a function
+//   carapace.ActionValues("dirty", "clean").StyleF(func(s string) string {
+//       if s == "dirty" {
+//           return style.Red
+//       }
+//       return style.Default
+//   })
+func (a Action) StyleF(f func(s string) string) Action {
+	return ActionCallback(func(c Context) Action {
+		invoked := a.Invoke(c)
+		for index, rawValue := range invoked.rawValues {
+			invoked.rawValues[index].Style = f(rawValue.Value)
 		}
+		return invoked.ToA()
+	}).withInvalid(a)
+}
 
-		if err := os.Chdir(dir); err != nil {
-			return ActionMessage(err.Error())
+// Tag sets the tag for all values which shells use to group and theme them
+// (built-in actions use "commands", "directories", "files", "flags" and "values")
+//   carapace.ActionValues("one", "two").Tag("numbers")
+func (a Action) Tag(tag string) Action {
+	return ActionCallback(func(c Context) Action {
+		invoked := a.Invoke(c)
+		for index := range invoked.rawValues {
+			invoked.rawValues[index].Tag = tag
 		}
+		return invoked.ToA()
+	}).withInvalid(a)
+}
 
-		a := a.Invoke(c).ToA()
+// KeepOrder keeps the order of values (these are otherwise sorted by display)
+func (a Action) KeepOrder() Action {
+	return a.keepOrder(true)
+}
 
-		if err := os.Chdir(current); err != nil {
-			return ActionMessage(err.Error())
-		}
-		return a
+// SortByValue sorts values by value (order is kept afterwards)
+func (a Action) SortByValue() Action {
+	return a.sort(func(rawValues []common.RawValue) { sort.Stable(common.ByValue(rawValues)) })
+}
+
+// SortByDisplay sorts values by display (order is kept afterwards)
+func (a Action) SortByDisplay() Action {
+	return a.sort(func(rawValues []common.RawValue) { sort.Stable(common.ByDisplay(rawValues)) })
+}
+
+// SortF sorts values using given comparator on value (order is kept afterwards)
+//   carapace.ActionValues("1", "10", "2").SortF(func(a, b string) bool {
+//       i, _ := strconv.Atoi(a)
+//       j, _ := strconv.Atoi(b)
+//       return i < j
+//   })
+func (a Action) SortF(less func(a, b string) bool) Action {
+	return a.sort(func(rawValues []common.RawValue) {
+		sort.SliceStable(rawValues, func(i, j int) bool {
+			return less(rawValues[i].Value, rawValues[j].Value)
+		})
 	})
 }
 
+func (a Action) sort(f func(rawValues []common.RawValue)) Action {
+	return ActionCallback(func(c Context) Action {
+		invoked := a.Invoke(c)
+		f(invoked.rawValues)
+		return invoked.ToA().keepOrder(true)
+	}).withInvalid(a)
+}
+
+// Match sets the strategy to match values against the word currently being completed (overrides Opts.Matcher)
+//   carapace.ActionValues("eu-prod-cluster", "us-prod-cluster").Match(match.Substring)
+func (a Action) Match(matcher match.Matcher) Action {
+	return a.match(matcher, false)
+}
+
+// MatchDescriptions additionally matches the descriptions of values
+func (a Action) MatchDescriptions() Action {
+	return a.match(nil, true)
+}
+
+// Timeout sets the maximum duration the invocation may take (returns an ActionMessage when exceeded)
+//   carapace.ActionExecCommand("slow", "command")(func(output []byte) carapace.Action {
+//       return carapace.ActionValues(strings.Split(string(output), "\n")...)
+//   }).Timeout(2 * time.Second)
+func (a Action) Timeout(timeout time.Duration) Action {
+	return ActionCallback(func(c Context) Action {
+		ctx, cancel := context.WithTimeout(c.Context, timeout)
+		defer cancel()
+		c.Context = ctx
+
+		result := make(chan InvokedAction, 1)
+		go func() {
+			result <- a.Invoke(c)
+		}()
+
+		select {
+		case invoked := <-result:
+			return invoked.ToA()
+		case <-ctx.Done():
+			return ActionMessage(fmt.Sprintf("timeout exceeded: %v", timeout))
+		}
+	}).withInvalid(a)
+}
+
 // Supress suppresses specific error messages using regular expressions
 func (a Action) Supress(expr ...string) Action {
 	return ActionCallback(func(c Context) Action {
@@ -157,7 +322,7 @@ func (a Action) Supress(expr ...string) Action {
 			invoked.rawValues = filtered
 		}
 		return invoked.ToA()
-	})
+	}).withInvalid(a)
 }
 
 func (a Action) noSpace(state bool) Action {
@@ -169,3 +334,21 @@ func (a Action) skipCache(state bool) Action {
 	a.skipcache = a.skipcache || state
 	return a
 }
+
+func (a Action) keepOrder(state bool) Action {
+	a.keeporder = a.keeporder || state
+	return a
+}
+
+func (a Action) match(matcher match.Matcher, descriptions bool) Action {
+	if a.matcher == nil {
+		a.matcher = matcher
+	}
+	a.matchdesc = a.matchdesc || descriptions
+	return a
+}
+
+func (a Action) withSegment(segment string) Action {
+	a.segment = segment
+	return a
+}
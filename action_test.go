@@ -1,13 +1,23 @@
 package carapace
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/user"
+	"path/filepath"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/rsteube/carapace/internal/assert"
+	"github.com/rsteube/carapace/internal/cache"
 	"github.com/rsteube/carapace/internal/common"
+	pkgcache "github.com/rsteube/carapace/pkg/cache"
+	"github.com/rsteube/carapace/pkg/match"
 )
 
 func assertEqual(t *testing.T, expected, actual InvokedAction) {
@@ -36,6 +46,156 @@ func TestActionCallback(t *testing.T) {
 	assertEqual(t, expected, actual)
 }
 
+func TestActionCache(t *testing.T) {
+	opts.Cache = pkgcache.NewMemoryCache()
+	defer func() { opts.Cache = nil }()
+
+	invocations := 0
+	a := ActionCallback(func(c Context) Action {
+		invocations++
+		return ActionValues(fmt.Sprint(invocations))
+	}).Cache(time.Hour)
+
+	for i := 0; i < 3; i++ {
+		assertEqual(t, ActionValues("1").Invoke(Context{}), a.Invoke(Context{}))
+	}
+	if infos, _ := cache.List(opts.Cache, ""); len(infos) != 1 {
+		t.Errorf("expected a single entry: %v", infos)
+	}
+}
+
+func TestActionCacheStale(t *testing.T) {
+	opts.Cache = pkgcache.NewMemoryCache()
+	defer func() { opts.Cache = nil }()
+
+	var invocations int32
+	a := ActionCallback(func(c Context) Action {
+		return ActionValues(fmt.Sprint(atomic.AddInt32(&invocations, 1)))
+	}).CacheStale(50 * time.Millisecond)
+
+	assertEqual(t, ActionValues("1").Invoke(Context{}), a.Invoke(Context{}))
+	time.Sleep(60 * time.Millisecond)
+	assertEqual(t, ActionValues("1").Invoke(Context{}), a.Invoke(Context{})) // stale values are returned immediately
+
+	for i := 0; i < 100 && atomic.LoadInt32(&invocations) < 2; i++ {
+		time.Sleep(time.Millisecond) // wait for background refresh
+	}
+	time.Sleep(5 * time.Millisecond)
+	assertEqual(t, ActionValues("2").Invoke(Context{}), a.Invoke(Context{}))
+}
+
+func TestActionCacheMetadata(t *testing.T) {
+	opts.Cache = pkgcache.NewMemoryCache()
+	defer func() { opts.Cache = nil }()
+
+	invocations := 0
+	a := ActionCallback(func(c Context) Action {
+		invocations++
+		return ActionStyledValues("b", "red", "a", "blue").NoSpace().KeepOrder().MatchDescriptions()
+	}).Cache(time.Hour)
+
+	expected := ActionStyledValues("b", "red", "a", "blue").NoSpace().KeepOrder().MatchDescriptions().Invoke(Context{})
+	assertEqual(t, expected, a.Invoke(Context{}))
+	assertEqual(t, expected, a.Invoke(Context{}))
+	if invocations != 1 {
+		t.Errorf("expected values to be cached: %v", invocations)
+	}
+
+	infos, _ := cache.List(opts.Cache, "")
+	opts.Cache.Write(infos[0].ID, []byte(`[{"Value":"outdated","Display":"outdated"}]`)) // format predating versioning
+	assertEqual(t, expected, a.Invoke(Context{}))
+	if invocations != 2 {
+		t.Errorf("expected outdated entry to be invalidated: %v", invocations)
+	}
+}
+
+func TestActionCacheConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "carapace-concurrent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	opts.Cache = pkgcache.NewFileCache(dir)
+	defer func() { opts.Cache = nil }()
+
+	var invocations int32
+	a := ActionCallback(func(c Context) Action {
+		atomic.AddInt32(&invocations, 1)
+		time.Sleep(20 * time.Millisecond)
+		return ActionValues("a", "b")
+	}).Cache(time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assertEqual(t, ActionValues("a", "b").Invoke(Context{}), a.Invoke(Context{}))
+		}()
+	}
+	wg.Wait()
+	if invocations != 1 {
+		t.Errorf("expected a single refresh: %v", invocations)
+	}
+
+	infos, _ := cache.List(opts.Cache, "")
+	opts.Cache.Write(infos[0].ID, []byte(`{"Version":1,"RawVal`)) // corrupt entry
+	assertEqual(t, ActionValues("a", "b").Invoke(Context{}), a.Invoke(Context{}))
+	if _, err := cache.Load(opts.Cache, infos[0].ID, 0); err != nil || invocations != 2 {
+		t.Errorf("expected corrupt entry to be repaired: %v %v", invocations, err)
+	}
+}
+
+func TestContextCacheKey(t *testing.T) {
+	opts.Cache = pkgcache.NewMemoryCache()
+	defer func() { opts.Cache = nil }()
+
+	invocations := 0
+	a := ActionCallback(func(c Context) Action {
+		return ActionCallback(func(c Context) Action {
+			invocations++
+			return ActionValues(c.Args...)
+		}).Cache(time.Hour, c.CacheKey())
+	})
+
+	assertEqual(t, ActionValues("a").Invoke(Context{}), a.Invoke(Context{Args: []string{"a"}}))
+	assertEqual(t, ActionValues("a").Invoke(Context{}), a.Invoke(Context{Args: []string{"a"}}))
+	assertEqual(t, ActionValues("b").Invoke(Context{}), a.Invoke(Context{Args: []string{"b"}}))
+	if invocations != 2 {
+		t.Errorf("expected a cache entry per context: %v", invocations)
+	}
+}
+
+func TestContextCacheKeyChdir(t *testing.T) {
+	opts.Cache = pkgcache.NewMemoryCache()
+	defer func() { opts.Cache = nil }()
+
+	dir, err := ioutil.TempDir("", "carapace-chdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, path := range []string{"first/a.go", "second/b.go"} {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0755)
+		ioutil.WriteFile(filepath.Join(dir, path), []byte{}, 0644)
+	}
+
+	invocations := 0
+	a := ActionCallback(func(c Context) Action {
+		return ActionCallback(func(c Context) Action {
+			invocations++
+			return ActionFiles().Invoke(c).ToA()
+		}).Cache(time.Hour, pkgcache.WorkingDir(c.Dir), pkgcache.DirStats(c.Dir, "."), pkgcache.GlobStats(c.Dir, "*.go"), pkgcache.Command(c.Dir, c.Env, "pwd"))
+	})
+
+	assertEqual(t, ActionValues("a.go").Tag("files").noSpace(true).Invoke(Context{}), a.Chdir(filepath.Join(dir, "first")).Invoke(Context{}))
+	assertEqual(t, ActionValues("b.go").Tag("files").noSpace(true).Invoke(Context{}), a.Chdir(filepath.Join(dir, "second")).Invoke(Context{}))
+	assertEqual(t, ActionValues("a.go").Tag("files").noSpace(true).Invoke(Context{}), a.Chdir(filepath.Join(dir, "first")).Invoke(Context{}))
+	if invocations != 2 {
+		t.Errorf("expected a cache entry per working directory: %v", invocations)
+	}
+}
+
 func TestSkipCache(t *testing.T) {
 	a := ActionCallback(func(c Context) Action {
 		return ActionValues().Invoke(c).Merge(
@@ -76,38 +236,194 @@ func TestNoSpace(t *testing.T) {
 
 func TestActionDirectories(t *testing.T) {
 	assertEqual(t,
-		ActionValues("example/", "docs/", "internal/", "pkg/").noSpace(true).Invoke(Context{}),
+		ActionValues("example/", "docs/", "internal/", "pkg/").Tag("directories").noSpace(true).Invoke(Context{}),
 		ActionDirectories().Invoke(Context{CallbackValue: ""}),
 	)
 
 	assertEqual(t,
-		ActionValues("example/", "docs/", "internal/", "pkg/").noSpace(true).Invoke(Context{}).Prefix("./"),
+		ActionValues("example/", "docs/", "internal/", "pkg/").Tag("directories").noSpace(true).Invoke(Context{}).prefixSegment("./"),
 		ActionDirectories().Invoke(Context{CallbackValue: "./"}),
 	)
 
 	assertEqual(t,
-		ActionValues("_test/", "cmd/").noSpace(true).Invoke(Context{}).Prefix("example/"),
+		ActionValues("_test/", "cmd/").Tag("directories").noSpace(true).Invoke(Context{}).prefixSegment("example/"),
 		ActionDirectories().Invoke(Context{CallbackValue: "example/"}),
 	)
 
 	assertEqual(t,
-		ActionValues("_test/", "cmd/").noSpace(true).Invoke(Context{}).Prefix("example/"),
+		ActionValues("_test/", "cmd/").Tag("directories").noSpace(true).Invoke(Context{}).prefixSegment("example/"),
 		ActionDirectories().Invoke(Context{CallbackValue: "example/cm"}),
 	)
 }
 
 func TestActionFiles(t *testing.T) {
 	assertEqual(t,
-		ActionValues("README.md", "example/", "docs/", "internal/", "pkg/").noSpace(true).Invoke(Context{}),
+		Batch(
+			ActionValues("README.md").Tag("files"),
+			ActionValues("example/", "docs/", "internal/", "pkg/").Tag("directories"),
+		).ToA().noSpace(true).Invoke(Context{}),
 		ActionFiles(".md").Invoke(Context{CallbackValue: ""}),
 	)
 
 	assertEqual(t,
-		ActionValues("_test/", "cmd/", "main.go", "main_test.go").noSpace(true).Invoke(Context{}).Prefix("example/"),
+		Batch(
+			ActionValues("_test/", "cmd/").Tag("directories"),
+			ActionValues("main.go", "main_test.go").Tag("files"),
+		).ToA().noSpace(true).Invoke(Context{}).prefixSegment("example/"),
 		ActionFiles().Invoke(Context{CallbackValue: "example/"}),
 	)
 }
 
+func TestActionFilesOpts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "carapace-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+	os.MkdirAll(filepath.Join(dir, "node_modules"), 0755)
+	os.MkdirAll(filepath.Join(dir, "build"), 0755)
+	os.MkdirAll(filepath.Join(dir, "src"), 0755)
+	for name, mode := range map[string]os.FileMode{
+		".gitignore":   0644,
+		"a.tar.gz":     0644,
+		"b.tar.xz":     0644,
+		"c.tar.bz2":    0644,
+		"deploy.sh":    0755,
+		"generated.go": 0644,
+	} {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte{}, mode)
+	}
+	ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte("build/\ngenerated.go\n"), 0644)
+
+	assertEqual(t,
+		Batch(
+			ActionValues("a.tar.gz", "b.tar.xz").Tag("files"),
+			ActionValues("build/", "node_modules/", "src/").Tag("directories"),
+		).ToA().noSpace(true).Invoke(Context{}),
+		ActionFilesOpts(FileOpts{Patterns: []string{"*.tar.{gz,xz}"}}).Invoke(Context{Dir: dir}),
+	)
+
+	assertEqual(t,
+		Batch(
+			ActionValues("a.tar.gz", "b.tar.xz", "c.tar.bz2", "deploy.sh").Tag("files"),
+			ActionValues("src/").Tag("directories"),
+		).ToA().noSpace(true).Invoke(Context{}),
+		ActionFilesOpts(FileOpts{Exclude: []string{"node_modules"}, Gitignore: true}).Invoke(Context{Dir: dir}),
+	)
+
+	assertEqual(t,
+		Batch(
+			ActionValues("deploy.sh").Tag("files"),
+			ActionValues("build/", "node_modules/", "src/").Tag("directories"),
+		).ToA().noSpace(true).Invoke(Context{}),
+		ActionFilesOpts(FileOpts{Executable: true}).Invoke(Context{Dir: dir}),
+	)
+
+	if os.Getuid() != 0 { // root ignores permissions
+		os.Chmod(filepath.Join(dir, "src"), 0)
+		defer os.Chmod(filepath.Join(dir, "src"), 0755)
+
+		assertEqual(t,
+			ActionValuesDescribed("src/", "permission denied").Tag("directories").noSpace(true).Invoke(Context{}),
+			ActionDirectories().Invoke(Context{Dir: dir, CallbackValue: "s"}).Filter([]string{"build/", "node_modules/"}),
+		)
+	}
+}
+
+func TestActionFilesExpand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "carapace-expand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "projects", "carapace", "docs"), 0755)
+
+	c := Context{Env: map[string]string{"WORKSPACE": dir}}
+	for _, prefix := range []string{"$WORKSPACE/", "${WORKSPACE}/"} {
+		c.CallbackValue = prefix
+		assertEqual(t,
+			ActionValues("projects/").Tag("directories").noSpace(true).Invoke(Context{}).prefixSegment(prefix),
+			ActionDirectories().Invoke(c),
+		)
+	}
+
+	c.CallbackValue = "$WORKSPACE/projects/carapace/d"
+	assertEqual(t,
+		ActionValues("docs/").Tag("directories").noSpace(true).Invoke(Context{}).prefixSegment("$WORKSPACE/projects/carapace/"),
+		ActionDirectories().Invoke(c),
+	)
+
+	assertEqual(t,
+		ActionValues("projects/").Tag("directories").noSpace(true).Invoke(Context{}).prefixSegment("~/"),
+		ActionDirectories().Invoke(Context{Env: map[string]string{"HOME": dir}, CallbackValue: "~/"}),
+	)
+
+	assertEqual(t,
+		ActionValuesDescribed("_", "", "ERR", "environment variable not set: UNSET").noSpace(true).Invoke(Context{}).prefixSegment("$UNSET/"),
+		ActionDirectories().Invoke(Context{Env: map[string]string{}, CallbackValue: "$UNSET/"}),
+	)
+
+	current, err := user.Current()
+	if err != nil {
+		t.Skip(err.Error())
+	}
+	os.Setenv("HOME", dir)
+	defer os.Setenv("HOME", current.HomeDir)
+	assertEqual(t,
+		ActionValues("projects/").Tag("directories").noSpace(true).Invoke(Context{}).prefixSegment("~/"),
+		ActionDirectories().Invoke(Context{CallbackValue: "~/"}),
+	)
+
+	if expanded, err := expandPath(Context{}, "~"+current.Username+"/.config"); err != nil || expanded != current.HomeDir+"/.config" {
+		t.Errorf("unexpected expansion: %v %v", expanded, err)
+	}
+}
+
+func TestActionDirectoriesRoots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "carapace-roots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, path := range []string{"work/api", "work/shared", "oss/carapace", "oss/shared"} {
+		os.MkdirAll(filepath.Join(dir, path), 0755)
+	}
+
+	assertEqual(t,
+		ActionValuesDescribed(
+			"api/", "work",
+			"shared/", "work",
+			"carapace/", "oss",
+		).Tag("directories").noSpace(true).Invoke(Context{}),
+		ActionDirectoriesRoots("work", "oss", "missing").Invoke(Context{Dir: dir}),
+	)
+
+	assertEqual(t,
+		ActionValuesDescribed(
+			"carapace/", "oss",
+			"shared/", "oss",
+			"api/", "work",
+		).Tag("directories").noSpace(true).Invoke(Context{}),
+		ActionDirectoriesRoots().Invoke(Context{Dir: dir, Env: map[string]string{"CDPATH": "oss:work"}}),
+	)
+
+	os.MkdirAll(filepath.Join(dir, "work/api/v1"), 0755)
+	assertEqual(t,
+		ActionValuesDescribed("v1/", "work").Tag("directories").noSpace(true).Invoke(Context{}).prefixSegment("api/"),
+		ActionDirectoriesRoots("oss", "work").Invoke(Context{Dir: dir, CallbackValue: "api/"}),
+	)
+}
+
+func TestActionFilesLsColors(t *testing.T) {
+	expected := Batch(
+		ActionStyledValues("README.md", "red").Tag("files"),
+		ActionStyledValues("example/", "bold blue", "docs/", "bold blue", "internal/", "bold blue", "pkg/", "bold blue").Tag("directories"),
+	).ToA().noSpace(true).Invoke(Context{})
+	assertEqual(t, expected, ActionFiles(".md").Invoke(Context{Env: map[string]string{"LS_COLORS": "di=01;34:*.md=31"}}))
+}
+
 func TestActionFilesChdir(t *testing.T) {
 	oldWd, _ := os.Getwd()
 
@@ -122,15 +438,102 @@ func TestActionFilesChdir(t *testing.T) {
 	)
 
 	assertEqual(t,
-		ActionValues("action.go", "snippet.go").noSpace(true).Invoke(Context{}).Prefix("elvish/"),
+		ActionValues("action.go", "snippet.go").Tag("files").noSpace(true).Invoke(Context{}).prefixSegment("elvish/"),
 		ActionFiles().Chdir("internal").Invoke(Context{CallbackValue: "elvish/"}),
 	)
 
+	assertEqual(t,
+		ActionValues("action.go", "snippet.go").Tag("files").noSpace(true).Invoke(Context{}).Prefix("elvish/"),
+		ActionFiles().Chdir("elvish").Chdir("internal").Invoke(Context{CallbackValue: ""}).Prefix("elvish/"),
+	)
+
 	if newWd, _ := os.Getwd(); oldWd != newWd {
 		t.Error("workdir should not be changed")
 	}
 }
 
+func TestActionFilesChdirBatch(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		assertEqual(t,
+			Batch(
+				ActionValues("action.go", "snippet.go").Tag("files").noSpace(true).Invoke(Context{}).Prefix("bash/").ToA(),
+				ActionValues("action.go", "snippet.go").Tag("files").noSpace(true).Invoke(Context{}).Prefix("zsh/").ToA(),
+			).Invoke(Context{}).Merge(),
+			Batch(
+				ActionFiles().Chdir("internal/bash").Invoke(Context{}).Prefix("bash/").ToA(),
+				ActionFiles().Chdir("internal/zsh").Invoke(Context{}).Prefix("zsh/").ToA(),
+			).Invoke(Context{}).Merge(),
+		)
+	}
+}
+
+func TestActionStyle(t *testing.T) {
+	expected := ActionValues("one", "two").Invoke(Context{})
+	for index := range expected.rawValues {
+		expected.rawValues[index].Style = "red"
+	}
+	assertEqual(t, expected, ActionValues("one", "two").Style("red").Invoke(Context{}))
+
+	assertEqual(t,
+		ActionStyledValuesDescribed("one", "", "green", "two", "", "red").Invoke(Context{}),
+		ActionValues("one", "two").StyleF(func(s string) string {
+			if s == "one" {
+				return "green"
+			}
+			return "red"
+		}).Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionStyledValuesDescribed("one", "", "green", "two", "", "red").Invoke(Context{}),
+		ActionStyledValues("one", "green", "two", "red").Invoke(Context{}),
+	)
+}
+
+func values(rawValues []common.RawValue) []string {
+	vals := make([]string, len(rawValues))
+	for index, rawValue := range rawValues {
+		vals[index] = rawValue.Value
+	}
+	return vals
+}
+
+func TestActionSort(t *testing.T) {
+	assert.Equal(t, "[a b c]", fmt.Sprint(values(ActionValues("c", "a", "b").Invoke(Context{}).orderedRawValues())))
+	assert.Equal(t, "[c a b]", fmt.Sprint(values(ActionValues("c", "a", "b").KeepOrder().Invoke(Context{}).orderedRawValues())))
+	assert.Equal(t, "[c a b]", fmt.Sprint(values(ActionValues("c", "a", "b").KeepOrder().Invoke(Context{}).Filter([]string{}).orderedRawValues())))
+	assert.Equal(t, "[x/a x/b x/c]", fmt.Sprint(values(ActionValues("c", "a", "b").SortByValue().Invoke(Context{}).Prefix("x/").orderedRawValues())))
+	assert.Equal(t, "[1 2 10]", fmt.Sprint(values(ActionValues("10", "2", "1").SortF(func(a, b string) bool {
+		return len(a) < len(b) || (len(a) == len(b) && a < b)
+	}).Invoke(Context{}).orderedRawValues())))
+
+	displayed := ActionValues("a", "b", "c").Invoke(Context{})
+	displayed.rawValues[0].Display = "z"
+	assert.Equal(t, "[b c a]", fmt.Sprint(values(displayed.orderedRawValues())))
+	assert.Equal(t, "[b c a]", fmt.Sprint(values(displayed.ToA().SortByDisplay().Invoke(Context{}).orderedRawValues())))
+	assert.Equal(t, "[a b c]", fmt.Sprint(values(displayed.ToA().SortByValue().Invoke(Context{}).orderedRawValues())))
+}
+
+func TestActionMatch(t *testing.T) {
+	a := ActionValuesDescribed("eu-prod-cluster", "production", "us-dev-cluster", "development")
+	assert.Equal(t, "[]", fmt.Sprint(values(a.Invoke(Context{}).filter("prod"))))
+	assert.Equal(t, "[eu-prod-cluster]", fmt.Sprint(values(a.Match(match.Substring).Invoke(Context{}).filter("prod"))))
+	assert.Equal(t, "[eu-prod-cluster us-dev-cluster]", fmt.Sprint(values(a.Match(match.Fuzzy).Invoke(Context{}).filter("ucl"))))
+	assert.Equal(t, "[us-dev-cluster]", fmt.Sprint(values(a.MatchDescriptions().Invoke(Context{}).filter("dev"))))
+	assert.Equal(t, "[]", fmt.Sprint(values(a.Match(match.Substring).Invoke(Context{}).filter("eu-cl"))))
+
+	multiparts := ActionMultiParts(",", func(c Context) Action { return a.Match(match.Substring) })
+	assert.Equal(t, "[x,eu-prod-cluster]", fmt.Sprint(values(multiparts.Invoke(Context{CallbackValue: "x,prod"}).filter("x,prod"))))
+	assert.Equal(t, "[y:x,eu-prod-cluster]", fmt.Sprint(values(multiparts.Invoke(Context{CallbackValue: "x,prod"}).Prefix("y:").filter("y:x,prod"))))
+	assert.Equal(t, "[]", fmt.Sprint(values(multiparts.Invoke(Context{CallbackValue: "x,prod"}).filter("z,prod"))))
+	assert.Equal(t, "[x,eu-prod-cluster]", fmt.Sprint(values(multiparts.Invoke(Context{CallbackValue: "x,prod"}).Merge(ActionValues().Invoke(Context{})).filter("x,prod"))))
+
+	opts.Matcher = match.CaseInsensitive
+	defer func() { opts.Matcher = nil }()
+	assert.Equal(t, "[eu-prod-cluster]", fmt.Sprint(values(a.Invoke(Context{}).filter("EU"))))
+	assert.Equal(t, "[]", fmt.Sprint(values(a.Match(match.Prefix).Invoke(Context{}).filter("EU"))))
+}
+
 func TestActionMessage(t *testing.T) {
 	assertEqual(t,
 		ActionValuesDescribed("_", "", "ERR", "example message").noSpace(true).skipCache(true).Invoke(Context{}).Prefix("docs/"),
@@ -158,4 +561,41 @@ func TestActionExecCommand(t *testing.T) {
 		ActionValues("module github.com/rsteube/carapace\n").Invoke(Context{}),
 		ActionExecCommand("head", "-n1", "go.mod")(func(output []byte) Action { return ActionValues(string(output)) }).Invoke(Context{}),
 	)
+
+	assertEqual(t,
+		ActionValues("action.go\nsnippet.go\n").Invoke(Context{}),
+		ActionExecCommand("ls")(func(output []byte) Action { return ActionValues(string(output)) }).Chdir("internal/elvish").Invoke(Context{}),
+	)
+}
+
+func TestActionTimeout(t *testing.T) {
+	assertEqual(t,
+		ActionValues("within").Invoke(Context{}),
+		ActionCallback(func(c Context) Action {
+			return ActionValues("within")
+		}).Timeout(time.Second).Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionMessage("timeout exceeded: 10ms").Invoke(Context{}),
+		ActionCallback(func(c Context) Action {
+			time.Sleep(time.Second)
+			return ActionValues("exceeded")
+		}).Timeout(10*time.Millisecond).Invoke(Context{}),
+	)
+
+	start := time.Now()
+	assertEqual(t,
+		ActionMessage("timeout exceeded: 10ms").Invoke(Context{}),
+		ActionExecCommand("sleep", "10")(func(output []byte) Action { return ActionValues() }).Timeout(10*time.Millisecond).Invoke(Context{}),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assertEqual(t,
+		ActionMessage("sleep: context deadline exceeded").Invoke(Context{}),
+		ActionExecCommand("sleep", "10")(func(output []byte) Action { return ActionValues() }).Invoke(Context{Context: ctx}),
+	)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("command was not killed at deadline (took %v)", elapsed)
+	}
 }
@@ -33,7 +33,7 @@ func (b batch) Invoke(c Context) invokedBatch {
 func (b batch) ToA() Action {
 	return ActionCallback(func(c Context) Action {
 		return b.Invoke(c).Merge().ToA()
-	})
+	}).withInvalid(b...)
 }
 
 // Merge merges Actions of a batch
@@ -1,8 +1,10 @@
 package carapace
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/rsteube/carapace/internal/assert"
 	"github.com/rsteube/carapace/internal/common"
 )
 
@@ -67,3 +69,14 @@ func TestBatchToA(t *testing.T) {
 	actual := b.ToA().Invoke(Context{})
 	assertEqual(t, expected, actual)
 }
+
+func TestBatchMergeOrder(t *testing.T) {
+	b := Batch(
+		ActionValues("C", "A"),
+		ActionValuesDescribed("B", "", "A", "overwritten"),
+		ActionValues("D"),
+	)
+	actual := b.Invoke(Context{}).Merge()
+	assert.Equal(t, "[C A B D]", fmt.Sprint(values(actual.rawValues)))
+	assert.Equal(t, "overwritten", actual.rawValues[1].Description)
+}
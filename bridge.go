@@ -0,0 +1,178 @@
+package carapace
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rsteube/carapace/internal/bridge"
+	"github.com/rsteube/carapace/internal/common"
+	"github.com/spf13/cobra"
+)
+
+// shellCompDirectiveKeepOrder is only available in newer cobra versions but might be returned by the bridged command
+const shellCompDirectiveKeepOrder = cobra.ShellCompDirective(1 << 5)
+
+// ActionCobra bridges completions of an external cobra based command using its hidden `__complete` command.
+// Given args are passed before Context.Args (e.g. for a subcommand). Best used with DisableFlagParsing.
+//   cmd := &cobra.Command{
+//       Use:                "kubectl",
+//       DisableFlagParsing: true,
+//   }
+//   carapace.Gen(cmd).PositionalAnyCompletion(
+//       carapace.ActionCobra("kubectl"),
+//   )
+func ActionCobra(executable string, args ...string) Action {
+	return ActionCallback(func(c Context) Action {
+		completeArgs := append([]string{"__complete"}, args...)
+		completeArgs = append(completeArgs, c.Args...)
+		completeArgs = append(completeArgs, c.CallbackValue)
+
+		return ActionExecCommand(executable, completeArgs...)(func(output []byte) Action {
+			return actionCobraOutput(string(output))
+		})
+	})
+}
+
+// actionCobraOutput parses the output of cobra's `__complete` command
+//   value\tdescription
+//   :directive
+func actionCobraOutput(output string) Action {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	directive := cobra.ShellCompDirectiveDefault
+	if last := lines[len(lines)-1]; strings.HasPrefix(last, ":") {
+		parsed, err := strconv.Atoi(last[1:])
+		if err != nil {
+			return ActionMessage(fmt.Sprintf("invalid directive: %v", last))
+		}
+		directive = cobra.ShellCompDirective(parsed)
+		lines = lines[:len(lines)-1]
+	}
+
+	if directive&cobra.ShellCompDirectiveError != 0 {
+		return ActionMessage("completion failed")
+	}
+
+	filtered := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "_activeHelp_ ") {
+			filtered = append(filtered, line)
+		}
+	}
+	rawValues := bridgedRawValues(filtered)
+
+	var a Action
+	switch {
+	case directive&cobra.ShellCompDirectiveFilterFileExt != 0:
+		extensions := make([]string, 0, len(rawValues))
+		for _, rawValue := range rawValues {
+			extensions = append(extensions, "."+rawValue.Value)
+		}
+		return ActionFiles(extensions...)
+	case directive&cobra.ShellCompDirectiveFilterDirs != 0:
+		if len(rawValues) > 0 {
+			return ActionDirectories().Chdir(rawValues[0].Value)
+		}
+		return ActionDirectories()
+	case len(rawValues) == 0 && directive&cobra.ShellCompDirectiveNoFileComp == 0:
+		return ActionFiles()
+	default:
+		a = actionRawValues(rawValues...)
+	}
+
+	if directive&cobra.ShellCompDirectiveNoSpace != 0 {
+		a = a.NoSpace()
+	}
+	if directive&shellCompDirectiveKeepOrder != 0 {
+		a = a.KeepOrder()
+	}
+	return a
+}
+
+// ActionBash bridges completions registered with `complete` in bash (e.g. by bash-completion).
+//   carapace.ActionBash("apt")
+func ActionBash(executable string, args ...string) Action {
+	return ActionCallback(func(c Context) Action {
+		words := append([]string{"_carapace_bridge", executable}, args...)
+		words = append(words, c.Args...)
+		words = append(words, c.CallbackValue)
+
+		return ActionExecCommand("bash", append([]string{"-c", bridge.Bash}, words...)...)(func(output []byte) Action {
+			return actionBridgedOutput(string(output))
+		})
+	})
+}
+
+// ActionFish bridges completions registered with `complete` in fish.
+//   carapace.ActionFish("git")
+func ActionFish(executable string, args ...string) Action {
+	return ActionCallback(func(c Context) Action {
+		return ActionExecCommandOpts(ExecOpts{
+			Env: []string{"CARAPACE_COMPLINE=" + bridgedLine(executable, args, c)},
+		}, "fish", "--no-config", "--command", bridge.Fish)(func(output []byte) Action {
+			return actionBridgedOutput(string(output))
+		})
+	})
+}
+
+// bridgedLine creates an escaped command line from executable, args and Context
+func bridgedLine(executable string, args []string, c Context) string {
+	words := append([]string{executable}, args...)
+	words = append(words, c.Args...)
+	words = append(words, c.CallbackValue)
+
+	replacer := strings.NewReplacer(
+		"\\", "\\\\",
+		" ", "\\ ",
+		"'", "\\'",
+		`"`, `\"`,
+		"$", "\\$",
+		"&", "\\&",
+		"|", "\\|",
+		";", "\\;",
+		"(", "\\(",
+		")", "\\)",
+		"<", "\\<",
+		">", "\\>",
+		"*", "\\*",
+		"?", "\\?",
+		"#", "\\#",
+		"[", "\\[",
+		"]", "\\]",
+		"{", "\\{",
+		"}", "\\}",
+	)
+	for index, word := range words {
+		words[index] = replacer.Replace(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// actionBridgedOutput converts lines of `value\tdescription` to an Action
+func actionBridgedOutput(output string) Action {
+	rawValues := bridgedRawValues(strings.Split(output, "\n"))
+	a := actionRawValues(rawValues...)
+	for _, rawValue := range rawValues {
+		if strings.HasSuffix(rawValue.Value, "/") || strings.HasSuffix(rawValue.Value, "=") {
+			a = a.NoSpace() // assume these need further completion (directory, optarg)
+			break
+		}
+	}
+	return a
+}
+
+func bridgedRawValues(lines []string) []common.RawValue {
+	rawValues := make([]common.RawValue, 0, len(lines))
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		splitted := strings.SplitN(line, "\t", 2)
+		rawValue := common.RawValue{Value: splitted[0], Display: splitted[0]}
+		if len(splitted) > 1 {
+			rawValue.Description = splitted[1]
+		}
+		rawValues = append(rawValues, rawValue)
+	}
+	return rawValues
+}
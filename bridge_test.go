@@ -0,0 +1,128 @@
+package carapace
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestActionCobraOutput(t *testing.T) {
+	assertEqual(t,
+		ActionValuesDescribed("one", "first", "two", "").Invoke(Context{}),
+		actionCobraOutput("one\tfirst\ntwo\n:4\n").Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionValues("one", "two").NoSpace().KeepOrder().Invoke(Context{}),
+		actionCobraOutput("_activeHelp_ some help\none\ntwo\n:38\n").Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionMessage("completion failed").Invoke(Context{}),
+		actionCobraOutput(":1\n").Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionFiles(".mod").Invoke(Context{}),
+		actionCobraOutput("mod\n:8\n").Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionDirectories().Chdir("internal").Invoke(Context{CallbackValue: "e"}),
+		actionCobraOutput("internal\n:16\n").Invoke(Context{CallbackValue: "e"}),
+	)
+
+	assertEqual(t,
+		ActionFiles().Invoke(Context{}),
+		actionCobraOutput(":0\n").Invoke(Context{}),
+	)
+}
+
+func TestActionCobra(t *testing.T) {
+	dir, err := ioutil.TempDir("", "carapace-cobra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	executable := filepath.Join(dir, "cobra-example")
+	script := "#!/bin/sh\nfor arg in \"$@\"; do printf '%s\\targ\\n' \"$arg\"; done\necho :4\n"
+	if err := ioutil.WriteFile(executable, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t,
+		ActionValuesDescribed("__complete", "arg", "sub", "arg", "positional", "arg", "cur", "arg").Invoke(Context{}),
+		ActionCobra(executable, "sub").Invoke(Context{Args: []string{"positional"}, CallbackValue: "cur"}),
+	)
+}
+
+func TestActionBash(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "carapace-bash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "completions"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "completions", "bridge-function"), []byte(`_bridge_function() { COMPREPLY=($(compgen -W "alpha beta previous-$3" -- "$2")); }
+complete -F _bridge_function bridge-function`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "completions", "bridge-wordlist"), []byte(`complete -W "one two three" bridge-wordlist`), 0644)
+
+	os.Setenv("BASH_COMPLETION_USER_DIR", dir)
+	defer os.Unsetenv("BASH_COMPLETION_USER_DIR")
+
+	assertEqual(t,
+		ActionValues("alpha", "beta", "previous-sub").Invoke(Context{}),
+		ActionBash("bridge-function", "sub").Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionValues("two", "three").Invoke(Context{}),
+		ActionBash("bridge-wordlist").Invoke(Context{Args: []string{"one"}, CallbackValue: "t"}),
+	)
+
+	assertEqual(t,
+		ActionValues().Invoke(Context{}),
+		ActionBash("bridge-unknown").Invoke(Context{}),
+	)
+}
+
+func TestActionFish(t *testing.T) {
+	if _, err := exec.LookPath("fish"); err != nil {
+		t.Skip("fish not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "carapace-fish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Mkdir(filepath.Join(dir, "beta"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "alpha.txt"), []byte{}, 0644)
+
+	assertEqual(t,
+		ActionValues("alpha.txt", "beta/").NoSpace().Invoke(Context{}),
+		ActionFish("bridge-unknown").Chdir(dir).Invoke(Context{}),
+	)
+}
+
+func TestBridgedLine(t *testing.T) {
+	if line := bridgedLine("git", []string{"commit"}, Context{Args: []string{"-m", "it's done"}, CallbackValue: "$HOME"}); line != `git commit -m it\'s\ done \$HOME` {
+		t.Errorf("unexpected line: %v", line)
+	}
+}
+
+func TestActionBridgedOutput(t *testing.T) {
+	assertEqual(t,
+		ActionValuesDescribed("dir/", "", "file", "a file").NoSpace().Invoke(Context{}),
+		actionBridgedOutput("dir/\nfile\ta file\n").Invoke(Context{}),
+	)
+}
@@ -0,0 +1,94 @@
+package carapace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rsteube/carapace/internal/cache"
+)
+
+// cacheCmd manages entries created by Action.Cache
+//   _carapace cache list [uid]  // entries with their age, size and keys
+//   _carapace cache stats [uid] // amount and size of entries per uid
+//   _carapace cache clear [uid] // removes entries
+func cacheCmd(out io.Writer, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New("usage: _carapace cache list|stats|clear [uid]")
+	}
+	uid := ""
+	if len(args) > 1 {
+		uid = args[1]
+	}
+
+	backend, err := cacheBackend()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		infos, err := cache.List(backend, uid)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "UID\tCALLER\tAGE\tSIZE\tKEYS")
+		for _, info := range infos {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", info.UID, info.Caller, time.Since(info.ModTime).Round(time.Second), info.Size, strings.Join(info.Keys, " "))
+		}
+		return w.Flush()
+
+	case "stats":
+		infos, err := cache.List(backend, uid)
+		if err != nil {
+			return err
+		}
+		type stats struct {
+			caller  string
+			entries int
+			size    int64
+		}
+		uids := make([]string, 0)
+		statsByUID := make(map[string]*stats)
+		total := stats{}
+		for _, info := range infos {
+			s, ok := statsByUID[info.UID]
+			if !ok {
+				s = &stats{}
+				statsByUID[info.UID] = s
+				uids = append(uids, info.UID)
+			}
+			if info.Caller != "" {
+				s.caller = info.Caller
+			}
+			s.entries++
+			s.size += info.Size
+			total.entries++
+			total.size += info.Size
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "UID\tCALLER\tENTRIES\tSIZE")
+		for _, uid := range uids {
+			s := statsByUID[uid]
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", uid, s.caller, s.entries, s.size)
+		}
+		fmt.Fprintf(w, "total\t\t%v\t%v\n", total.entries, total.size)
+		return w.Flush()
+
+	case "clear":
+		count, err := cache.Clear(backend, uid)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "removed %v entries\n", count)
+		return err
+
+	default:
+		return fmt.Errorf("unknown cache command: %v", args[0])
+	}
+}
@@ -0,0 +1,65 @@
+package carapace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsteube/carapace/internal/cache"
+	pkgcache "github.com/rsteube/carapace/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+func execCacheCmd(args ...string) string {
+	rootCmd := &cobra.Command{
+		Use: "root",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+	Gen(rootCmd)
+
+	out := &bytes.Buffer{}
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(out)
+	rootCmd.SetArgs(append([]string{"_carapace", "cache"}, args...))
+	rootCmd.Execute()
+	return out.String()
+}
+
+func TestCacheCmd(t *testing.T) {
+	opts.Cache = pkgcache.NewMemoryCache()
+	defer func() { opts.Cache = nil }()
+
+	first := ActionValues("first").Cache(time.Hour, pkgcache.String("one"))
+	second := ActionValues("second").Cache(time.Hour, pkgcache.String("two"))
+	first.Invoke(Context{})
+	second.Invoke(Context{})
+	second.Cache(time.Hour, pkgcache.String("three")).Invoke(Context{})
+
+	list := execCacheCmd("list")
+	if lines := strings.Split(strings.TrimSpace(list), "\n"); len(lines) != 4 {
+		t.Errorf("expected header and 3 entries:\n%v", list)
+	}
+	for _, expected := range []string{"cache_test.go:", " one", " two", " three"} {
+		if !strings.Contains(list, expected) {
+			t.Errorf("expected %#v in:\n%v", expected, list)
+		}
+	}
+
+	stats := execCacheCmd("stats")
+	if !strings.Contains(stats, "total") || !strings.Contains(stats, "  3  ") {
+		t.Errorf("unexpected stats:\n%v", stats)
+	}
+
+	infos, _ := cache.List(opts.Cache, "")
+	uid := infos[0].UID
+	if out := execCacheCmd("clear", uid); out != "removed 1 entries\n" {
+		t.Errorf("unexpected output: %v", out)
+	}
+	if out := execCacheCmd("clear"); out != "removed 2 entries\n" {
+		t.Errorf("unexpected output: %v", out)
+	}
+	if out := execCacheCmd("unknown"); !strings.Contains(out, "unknown cache command") {
+		t.Errorf("unexpected output: %v", out)
+	}
+}
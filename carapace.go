@@ -34,6 +34,7 @@ type Carapace struct {
 // Gen initialized Carapace for given command
 func Gen(cmd *cobra.Command) *Carapace {
 	addCompletionCommand(cmd)
+	storage.get(cmd).gen = true
 
 	cobra.OnInitialize(func() {
 		if opts.BridgeCompletion {
@@ -129,6 +130,20 @@ func addCompletionCommand(cmd *cobra.Command) {
 		Run: func(cmd *cobra.Command, args []string) {
 			logger.Println(os.Args) // TODO replace last with '' if empty
 
+			if len(args) > 0 && args[0] == "cache" {
+				if err := cacheCmd(cmd.OutOrStdout(), args[1:]); err != nil {
+					fmt.Fprintln(io.MultiWriter(cmd.ErrOrStderr(), logger.Writer()), err.Error())
+				}
+				return
+			}
+
+			if len(args) > 0 && args[0] == "coverage" {
+				if err := coverageCmd(cmd.OutOrStdout(), cmd.Root(), args[1:]); err != nil {
+					fmt.Fprintln(io.MultiWriter(cmd.ErrOrStderr(), logger.Writer()), err.Error())
+				}
+				return
+			}
+
 			if len(args) == 0 {
 				if s, err := Gen(cmd).Snippet(ps.DetermineShell()); err != nil {
 					fmt.Fprintln(io.MultiWriter(os.Stderr, logger.Writer()), err.Error())
@@ -149,7 +164,7 @@ func addCompletionCommand(cmd *cobra.Command) {
 					previous := args[len(args)-2]
 
 					targetCmd, targetArgs, err := findTarget(cmd, args)
-					context := Context{CallbackValue: current, Args: targetArgs}
+					context := newContext(shell, targetCmd, current, targetArgs)
 					if err != nil {
 						if opts.LongShorthand {
 							current = strings.TrimPrefix(current, "-")
@@ -163,6 +178,7 @@ func addCompletionCommand(cmd *cobra.Command) {
 						// TODO needs more cleanup and tests
 						var targetAction Action
 						if flag := lookupFlag(targetCmd, previous); !targetCmd.DisableFlagParsing && flag != nil && flag.NoOptDefVal == "" { // previous arg is a flag and needs a value
+							context.dropFlagValue(flag, current)
 							targetAction = storage.getFlag(targetCmd, flag.Name)
 						} else if !targetCmd.DisableFlagParsing && strings.HasPrefix(current, "-") { // assume flag
 							if strings.Contains(current, "=") { // complete value for optarg flag
@@ -170,11 +186,15 @@ func addCompletionCommand(cmd *cobra.Command) {
 									a := storage.getFlag(targetCmd, flag.Name)
 									splitted := strings.SplitN(current, "=", 2)
 									context.CallbackValue = splitted[1]
+									context.dropFlagValue(flag, splitted[1])
 									if opts.LongShorthand {
 										splitted[0] = splitted[0][1:] // revert the added `-` so that the resulting prefix is correct
 									}
-									current = strings.Replace(current, "=", opts.OptArgDelimiter, 1)                  // revert (potentially) overridden optarg divider for `.value()` invocation below
-									targetAction = a.Invoke(context).Prefix(splitted[0] + opts.OptArgDelimiter).ToA() // prefix with (potentially) overridden optarg delimiter
+									current = strings.Replace(current, "=", opts.OptArgDelimiter, 1) // revert (potentially) overridden optarg divider for `.value()` invocation below
+									prefix := splitted[0] + opts.OptArgDelimiter                     // prefix with (potentially) overridden optarg delimiter
+									targetAction = ActionCallback(func(c Context) Action {
+										return a.Invoke(c).prefixSegment(prefix).ToA()
+									})
 								}
 							} else { // complete flagnames
 								targetAction = actionFlags(targetCmd)
@@ -186,14 +206,16 @@ func addCompletionCommand(cmd *cobra.Command) {
 
 							targetAction = findAction(targetCmd, targetArgs)
 							if targetCmd.HasAvailableSubCommands() && len(targetArgs) <= 1 {
-								subcommandA := actionSubcommands(targetCmd).Invoke(context)
-								targetAction = targetAction.Invoke(context).Merge(subcommandA).ToA()
+								positionalA := targetAction
+								targetAction = ActionCallback(func(c Context) Action {
+									return positionalA.Invoke(c).Merge(actionSubcommands(targetCmd).Invoke(c)).ToA()
+								})
 							}
 						}
 						if opts.LongShorthand {
 							current = strings.TrimPrefix(current, "-")
 						}
-						fmt.Fprintln(io.MultiWriter(os.Stdout, logger.Writer()), targetAction.Invoke(context).value(shell, current))
+						fmt.Fprintln(io.MultiWriter(os.Stdout, logger.Writer()), withTimeout(targetAction).Invoke(context).value(shell, current))
 					default:
 						// TODO disable support for direct uid invocation
 						//fmt.Fprintln(io.MultiWriter(os.Stdout, logger.Writer()), actionMap.invokeCallback(id, context).Invoke(context).value(shell, context.CallbackValue))
@@ -261,7 +283,7 @@ type testingT interface {
 	Error(args ...interface{})
 }
 
-// Test verifies the configuration (e.g. flag name exists)
+// Test verifies the configuration (e.g. flag name exists, positional arguments accepted, command passed to Gen)
 //   func TestCarapace(t *testing.T) {
 //       carapace.Test(t)
 //   }
@@ -16,12 +16,18 @@ func execCompletion(args ...string) (context Context) {
 		Run: func(cmd *cobra.Command, args []string) {},
 	}
 	rootCmd.Flags().String("multiparts", "", "")
+	rootCmd.Flags().StringArray("array", []string{}, "")
+	rootCmd.Flags().StringSlice("slice", []string{}, "")
 
 	Gen(rootCmd).FlagCompletion(ActionMap{
 		"multiparts": ActionMultiParts(",", func(c Context) Action {
 			context = c
 			return ActionValues()
 		}),
+		"array": ActionCallback(func(c Context) Action {
+			context = c
+			return ActionValues()
+		}),
 	})
 
 	Gen(rootCmd).PositionalAnyCompletion(
@@ -64,6 +70,12 @@ func testContext(t *testing.T, expected Context, args ...string) {
 		os.Stdout = sOut
 		os.Stderr = sErr
 
+		if actual.Dir == "" || actual.Env == nil {
+			t.Error("Dir and Env should be set")
+		}
+		actual.Dir = "" // environment specific
+		actual.Env = nil
+
 		e, _ := json.Marshal(expected)
 		a, _ := json.Marshal(actual)
 		assert.Equal(t, string(e), string(a))
@@ -75,6 +87,8 @@ func TestContext(t *testing.T) {
 		CallbackValue: "",
 		Args:          []string{},
 		Parts:         []string{},
+		Flags:         map[string][]string{},
+		Shell:         "elvish",
 	},
 		"")
 
@@ -82,6 +96,8 @@ func TestContext(t *testing.T) {
 		CallbackValue: "",
 		Args:          []string{"pos1"},
 		Parts:         []string{},
+		Flags:         map[string][]string{},
+		Shell:         "elvish",
 	},
 		"pos1", "")
 
@@ -89,6 +105,8 @@ func TestContext(t *testing.T) {
 		CallbackValue: "po",
 		Args:          []string{"pos1", "pos2"},
 		Parts:         []string{},
+		Flags:         map[string][]string{},
+		Shell:         "elvish",
 	},
 		"pos1", "pos2", "po")
 
@@ -96,6 +114,8 @@ func TestContext(t *testing.T) {
 		CallbackValue: "",
 		Args:          []string{},
 		Parts:         []string{},
+		Flags:         map[string][]string{},
+		Shell:         "elvish",
 	},
 		"--multiparts", "")
 
@@ -103,6 +123,8 @@ func TestContext(t *testing.T) {
 		CallbackValue: "fir",
 		Args:          []string{},
 		Parts:         []string{},
+		Flags:         map[string][]string{},
+		Shell:         "elvish",
 	},
 		"--multiparts", "fir")
 
@@ -110,6 +132,8 @@ func TestContext(t *testing.T) {
 		CallbackValue: "seco",
 		Args:          []string{"pos1"},
 		Parts:         []string{"first"},
+		Flags:         map[string][]string{},
+		Shell:         "elvish",
 	},
 		"pos1", "--multiparts", "first,seco")
 
@@ -117,6 +141,8 @@ func TestContext(t *testing.T) {
 		CallbackValue: "pos",
 		Args:          []string{},
 		Parts:         []string{},
+		Flags:         map[string][]string{},
+		Shell:         "elvish",
 	},
 		"pos")
 
@@ -124,6 +150,8 @@ func TestContext(t *testing.T) {
 		CallbackValue: "sec",
 		Args:          []string{},
 		Parts:         []string{"first"},
+		Flags:         map[string][]string{},
+		Shell:         "elvish",
 	},
 		"first:sec")
 
@@ -131,6 +159,35 @@ func TestContext(t *testing.T) {
 		CallbackValue: "thi",
 		Args:          []string{"first:second"},
 		Parts:         []string{},
+		Flags:         map[string][]string{},
+		Shell:         "elvish",
 	},
 		"first:second", "thi")
+
+	testContext(t, Context{
+		CallbackValue: "",
+		Args:          []string{"pos1"},
+		Parts:         []string{},
+		Flags:         map[string][]string{"array": {"one", "two"}},
+		Shell:         "elvish",
+	},
+		"--array", "one", "pos1", "--array", "two", "")
+
+	testContext(t, Context{
+		CallbackValue: "thr",
+		Args:          []string{},
+		Parts:         []string{},
+		Flags:         map[string][]string{"array": {"one", "two"}},
+		Shell:         "elvish",
+	},
+		"--array", "one", "--array", "two", "--array", "thr")
+
+	testContext(t, Context{
+		CallbackValue: "",
+		Args:          []string{},
+		Parts:         []string{"three"},
+		Flags:         map[string][]string{"slice": {"one", "two"}},
+		Shell:         "elvish",
+	},
+		"--slice", "one,two", "--multiparts", "three,")
 }
@@ -10,7 +10,7 @@ import (
 func registerValidArgsFunction(cmd *cobra.Command) {
 	if cmd.ValidArgsFunction == nil {
 		cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			action := storage.getPositional(cmd, len(args)).Invoke(Context{Args: args, CallbackValue: toComplete})
+			action := withTimeout(storage.getPositional(cmd, len(args))).Invoke(newContext("", cmd, toComplete, args))
 			return cobraValuesFor(action), cobraDirectiveFor(action)
 		}
 	}
@@ -20,15 +20,16 @@ func registerFlagCompletion(cmd *cobra.Command) {
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
 		cmd.RegisterFlagCompletionFunc(f.Name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			a := storage.getFlag(cmd, f.Name)
-			action := a.Invoke(Context{Args: args, CallbackValue: toComplete})
+			action := withTimeout(a).Invoke(newContext("", cmd, toComplete, args))
 			return cobraValuesFor(action), cobraDirectiveFor(action)
 		})
 	})
 }
 
 func cobraValuesFor(action InvokedAction) []string {
-	result := make([]string, len(action.rawValues))
-	for index, r := range action.rawValues {
+	rawValues := action.orderedRawValues()
+	result := make([]string, len(rawValues))
+	for index, r := range rawValues {
 		if r.Description != "" {
 			result[index] = fmt.Sprintf("%v\t%v", r.Value, r.Description)
 		} else {
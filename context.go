@@ -0,0 +1,155 @@
+package carapace
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rsteube/carapace/pkg/cache"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Context provides information during completion
+type Context struct {
+	// Context carries the deadline and cancellation signal of the completion
+	//   exec.CommandContext(c, "git", "branch")
+	context.Context `json:"-"`
+	// CallbackValue contains the (partial) value (or part of it during an ActionMultiParts) currently being completed
+	CallbackValue string
+	// Args contains the positional arguments of current (sub)command (exclusive the one currently being completed)
+	Args []string
+	// Parts contains the splitted CallbackValue during an ActionMultiParts (exclusive the part currently being completed)
+	Parts []string
+	// Cmd contains the (sub)command currently being completed
+	Cmd *cobra.Command `json:"-"`
+	// Flags contains the values of flags parsed so far (exclusive the one currently being completed)
+	//   --array one --array two // {"array": ["one", "two"]}
+	Flags map[string][]string
+	// Shell contains the name of the shell completion is done for
+	Shell string
+	// Dir contains the working directory (relative paths are resolved against it, see Action.Chdir)
+	Dir string
+	// Env contains the environment variables
+	Env map[string]string
+}
+
+// newContext creates a Context for given command populated with the current working directory and environment
+func newContext(shell string, cmd *cobra.Command, callbackValue string, args []string) Context {
+	context := Context{
+		Context:       context.Background(),
+		CallbackValue: callbackValue,
+		Args:          args,
+		Cmd:           cmd,
+		Flags:         make(map[string][]string),
+		Shell:         shell,
+		Env:           make(map[string]string),
+	}
+
+	if cmd != nil && !cmd.DisableFlagParsing {
+		cmd.Flags().Visit(func(f *pflag.Flag) {
+			context.Flags[f.Name] = flagValues(f)
+		})
+	}
+
+	if wd, err := os.Getwd(); err == nil {
+		context.Dir = wd
+	}
+
+	for _, e := range os.Environ() {
+		if splitted := strings.SplitN(e, "=", 2); len(splitted) == 2 {
+			context.Env[splitted[0]] = splitted[1]
+		}
+	}
+	return context
+}
+
+// flagValues returns the values of given flag (slice and array flags in order of appearance)
+func flagValues(f *pflag.Flag) []string {
+	if sliceValue, ok := f.Value.(pflag.SliceValue); ok {
+		return sliceValue.GetSlice()
+	}
+	return []string{f.Value.String()}
+}
+
+// dropFlagValue removes the value currently being completed from the parsed values of given flag
+func (c *Context) dropFlagValue(f *pflag.Flag, value string) {
+	dropped := []string{value}
+	if strings.Contains(f.Value.Type(), "Slice") {
+		dropped = strings.Split(value, ",") // slice flags split values by comma
+	}
+
+	values := c.Flags[f.Name]
+	if len(values) < len(dropped) {
+		return
+	}
+	for index, d := range dropped {
+		if values[len(values)-len(dropped)+index] != d {
+			return // value was not parsed (e.g. invalid int)
+		}
+	}
+
+	if remaining := values[:len(values)-len(dropped)]; len(remaining) > 0 {
+		c.Flags[f.Name] = remaining
+	} else {
+		delete(c.Flags, f.Name)
+	}
+}
+
+// abs resolves given path relative to the working directory (Context.Dir)
+func (c Context) abs(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	if c.Dir == "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs
+		}
+		return path
+	}
+	return filepath.Join(c.Dir, path)
+}
+
+// Getenv retrieves the value of the environment variable named by the key
+func (c Context) Getenv(key string) string {
+	if c.Env == nil {
+		return os.Getenv(key) // Context was not created during completion
+	}
+	return c.Env[key]
+}
+
+// lookupEnv is like Getenv but also reports whether the variable is set
+func (c Context) lookupEnv(key string) (string, bool) {
+	if c.Env == nil {
+		return os.LookupEnv(key) // Context was not created during completion
+	}
+	value, ok := c.Env[key]
+	return value, ok
+}
+
+// Environ returns the environment variables (Context.Env) in the form "key=value"
+func (c Context) Environ() []string {
+	if c.Env == nil {
+		return os.Environ() // Context was not created during completion
+	}
+	environ := make([]string, 0, len(c.Env))
+	for key, value := range c.Env {
+		environ = append(environ, key+"="+value)
+	}
+	sort.Strings(environ)
+	return environ
+}
+
+// CacheKey creates a CacheKey for the arguments, parts, callback value and working directory of the Context
+//   carapace.ActionCallback(func(c carapace.Context) carapace.Action {
+//       return carapace.ActionValues(c.Args...).Cache(time.Hour, c.CacheKey())
+//   })
+func (c Context) CacheKey() cache.Key {
+	return func() (string, error) {
+		m, err := json.Marshal([]interface{}{c.Args, c.Parts, c.CallbackValue, c.Dir})
+		return string(m), err
+	}
+}
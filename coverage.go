@@ -0,0 +1,175 @@
+package carapace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rsteube/carapace/internal/uid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// maxCoverageArgs is the amount of positional arguments probed to determine those accepted by a command
+const maxCoverageArgs = 20
+
+// CommandCoverage contains the completion coverage of a command
+type CommandCoverage struct {
+	Command       string   `json:"command"`       // uid of the command
+	Flags         []string `json:"flags"`         // flags taking a value with completion
+	MissingFlags  []string `json:"missingFlags"`  // flags taking a value without completion
+	Positional    int      `json:"positional"`    // amount of positional arguments with completion
+	PositionalAny bool     `json:"positionalAny"` // completion for any further positional argument
+	Args          int      `json:"args"`          // amount of positional arguments accepted (-1 if arbitrary, see acceptedArgs)
+}
+
+// MissingPositional returns the positions (starting at 1) of accepted positional arguments without completion
+func (c CommandCoverage) MissingPositional() []int {
+	missing := make([]int, 0)
+	if !c.PositionalAny {
+		for position := c.Positional + 1; position <= c.Args; position++ {
+			missing = append(missing, position)
+		}
+	}
+	return missing
+}
+
+// Coverage reports which flags and positional arguments of given command and its subcommands have completion
+// (hidden and deprecated commands are skipped)
+func Coverage(cmd *cobra.Command) []CommandCoverage {
+	coverages := make([]CommandCoverage, 0)
+	if ignoredCommand(cmd) {
+		return coverages
+	}
+
+	coverage := CommandCoverage{
+		Command:      uid.Command(cmd),
+		Flags:        make([]string, 0),
+		MissingFlags: make([]string, 0),
+		Args:         acceptedArgs(cmd),
+	}
+	e, ok := storage[cmd]
+	if !ok {
+		e = &entry{}
+	}
+
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || !takesValue(f) {
+			return // nothing to complete
+		}
+		if _, ok := e.flag[f.Name]; ok {
+			coverage.Flags = append(coverage.Flags, f.Name)
+		} else {
+			coverage.MissingFlags = append(coverage.MissingFlags, f.Name)
+		}
+	})
+	coverage.Positional = len(e.positional)
+	coverage.PositionalAny = e.positionalAny.callback != nil || e.positionalAny.rawValues != nil
+	coverages = append(coverages, coverage)
+
+	for _, subcmd := range cmd.Commands() {
+		coverages = append(coverages, Coverage(subcmd)...)
+	}
+	return coverages
+}
+
+// acceptedArgs returns the maximum amount of positional arguments accepted by the command (-1 if arbitrary)
+// The Args validator is probed with empty placeholders (the first of ValidArgs if set), so one rejecting
+// these (e.g. requiring existing files) is reported to accept 0 arguments.
+func acceptedArgs(cmd *cobra.Command) int {
+	if cmd.Args == nil {
+		return -1
+	}
+	accepted := 0
+	for count := 1; count <= maxCoverageArgs; count++ {
+		if acceptsArgs(cmd, count) == nil {
+			accepted = count
+		}
+	}
+	if accepted == maxCoverageArgs {
+		return -1
+	}
+	return accepted
+}
+
+// coverageCmd reports the completion coverage of given command
+//   _carapace coverage      // table with covered/total flags and positional arguments per command
+//   _carapace coverage json // Coverage as json
+func coverageCmd(out io.Writer, cmd *cobra.Command, args []string) error {
+	format := "text"
+	if len(args) > 1 {
+		return errors.New("usage: _carapace coverage [text|json]")
+	} else if len(args) == 1 {
+		format = args[0]
+	}
+
+	coverages := Coverage(cmd)
+	switch format {
+	case "json":
+		m, err := json.MarshalIndent(coverages, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(m))
+		return err
+
+	case "text":
+		table := &bytes.Buffer{}
+		w := tabwriter.NewWriter(table, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "COMMAND\tFLAGS\tPOSITIONAL\tMISSING")
+		var flags, totalFlags, positional, totalPositional int
+		arbitrary := false
+		for _, c := range coverages {
+			missing := make([]string, 0)
+			for _, name := range c.MissingFlags {
+				missing = append(missing, "--"+name)
+			}
+			for _, position := range c.MissingPositional() {
+				missing = append(missing, fmt.Sprintf("#%v", position))
+			}
+
+			positionalCoverage := fmt.Sprintf("%v/*", c.Positional) // arbitrary amount of arguments
+			if c.Args < 0 {
+				positional += c.Positional
+				arbitrary = true
+			} else {
+				covered := c.Args - len(c.MissingPositional())
+				positionalCoverage = fmt.Sprintf("%v/%v", covered, c.Args)
+				positional += covered
+				totalPositional += c.Args
+			}
+			if c.PositionalAny {
+				positionalCoverage += " (any)"
+			}
+
+			fmt.Fprintf(w, "%v\t%v/%v\t%v\t%v\n", c.Command, len(c.Flags), len(c.Flags)+len(c.MissingFlags), positionalCoverage, strings.Join(missing, " "))
+			flags += len(c.Flags)
+			totalFlags += len(c.Flags) + len(c.MissingFlags)
+		}
+		totalPositionalCoverage := fmt.Sprintf("%v/%v", positional, totalPositional)
+		switch {
+		case arbitrary && totalPositional == 0:
+			totalPositionalCoverage = fmt.Sprintf("%v/*", positional)
+		case arbitrary:
+			totalPositionalCoverage += "+*"
+		}
+		fmt.Fprintf(w, "total\t%v/%v\t%v\t\n", flags, totalFlags, totalPositionalCoverage)
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n") {
+			if _, err := fmt.Fprintln(out, strings.TrimRight(line, " ")); err != nil { // padding of an empty MISSING column
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
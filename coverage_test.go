@@ -0,0 +1,71 @@
+package carapace
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func coverageCommand() *cobra.Command {
+	rootCmd := &cobra.Command{Use: "coverage"}
+	rootCmd.Flags().String("covered", "", "")
+	rootCmd.Flags().String("missing", "", "")
+	rootCmd.Flags().Bool("toggle", false, "")
+	rootCmd.PersistentFlags().String("persistent", "", "")
+
+	exactCmd := &cobra.Command{Use: "exact", Args: cobra.ExactArgs(3)}
+	anyCmd := &cobra.Command{Use: "any", Args: cobra.RangeArgs(0, 2)}
+	hiddenCmd := &cobra.Command{Use: "hidden", Hidden: true}
+	rootCmd.AddCommand(exactCmd, anyCmd, hiddenCmd)
+
+	Gen(rootCmd).FlagCompletion(ActionMap{
+		"covered":    ActionValues(),
+		"persistent": ActionValues(),
+	})
+	Gen(exactCmd).PositionalCompletion(ActionValues())
+	Gen(anyCmd).PositionalAnyCompletion(ActionValues())
+	return rootCmd
+}
+
+func TestCoverage(t *testing.T) {
+	coverages := Coverage(coverageCommand())
+	m, _ := json.Marshal(coverages)
+	expected := `[` +
+		`{"command":"_coverage","flags":["covered","persistent"],"missingFlags":["missing"],"positional":0,"positionalAny":false,"args":-1},` +
+		`{"command":"_coverage__any","flags":[],"missingFlags":[],"positional":0,"positionalAny":true,"args":2},` +
+		`{"command":"_coverage__exact","flags":[],"missingFlags":[],"positional":1,"positionalAny":false,"args":3}` +
+		`]`
+	if string(m) != expected {
+		t.Errorf("expected:\n%v\nactual:\n%v", expected, string(m))
+	}
+	if missing := coverages[2].MissingPositional(); len(missing) != 2 || missing[0] != 2 || missing[1] != 3 {
+		t.Errorf("unexpected missing positional arguments: %v", missing)
+	}
+}
+
+func TestCoverageCmd(t *testing.T) {
+	out := &bytes.Buffer{}
+	if err := coverageCmd(out, coverageCommand(), []string{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `COMMAND           FLAGS  POSITIONAL  MISSING
+_coverage         2/3    0/*         --missing
+_coverage__any    0/0    2/2 (any)
+_coverage__exact  0/0    1/3         #2 #3
+total             2/3    3/5+*
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%v\nactual:\n%v", expected, out.String())
+	}
+
+	out.Reset()
+	if err := coverageCmd(out, coverageCommand(), []string{"json"}); err != nil || !strings.HasPrefix(out.String(), "[\n  {\n    \"command\": \"_coverage\"") {
+		t.Errorf("unexpected json: %v %v", out.String(), err)
+	}
+	if err := coverageCmd(out, coverageCommand(), []string{"xml"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -1,15 +1,10 @@
 package carapace
 
 import (
-	"bytes"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"fmt"
 	"regexp"
 	"strings"
 
-	exec "golang.org/x/sys/execabs"
-
 	"github.com/rsteube/carapace/internal/common"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -21,26 +16,14 @@ func ActionCallback(callback CompletionCallback) Action {
 }
 
 // ActionExecCommand invokes given command and transforms its output using given function on success or returns ActionMessage with the first line of stderr if available.
+// The command is killed when the deadline of the Context is exceeded (see Action.Timeout).
+// See ActionExecCommandOpts for further control over the invocation (env, stdin, exit codes, ...).
 //   carapace.ActionExecCommand("git", "remote")(func(output []byte) carapace.Action {
 //     lines := strings.Split(string(output), "\n")
 //     return carapace.ActionValues(lines[:len(lines)-1]...)
 //   })
 func ActionExecCommand(name string, arg ...string) func(f func(output []byte) Action) Action {
-	return func(f func(output []byte) Action) Action {
-		return ActionCallback(func(c Context) Action {
-			var stdout, stderr bytes.Buffer
-			cmd := exec.Command(name, arg...)
-			cmd.Stdout = &stdout
-			cmd.Stderr = &stderr
-			if err := cmd.Run(); err != nil {
-				if firstLine := strings.SplitN(stderr.String(), "\n", 2)[0]; strings.TrimSpace(firstLine) != "" {
-					return ActionMessage(stripAnsi(firstLine))
-				}
-				return ActionMessage(err.Error())
-			}
-			return f(stdout.Bytes())
-		})
-	}
+	return ActionExecCommandOpts(ExecOpts{}, name, arg...)
 }
 
 // strip ANSI color escape codes from string (source: https://github.com/acarl005/stripansi)
@@ -49,73 +32,6 @@ func stripAnsi(str string) string {
 	return re.ReplaceAllString(str, "")
 }
 
-// ActionDirectories completes directories
-func ActionDirectories() Action {
-	return ActionCallback(func(c Context) Action {
-		return actionPath([]string{""}, true).Invoke(c).ToMultiPartsA("/").noSpace(true)
-	})
-}
-
-// ActionFiles completes files with optional suffix filtering
-func ActionFiles(suffix ...string) Action {
-	return ActionCallback(func(c Context) Action {
-		return actionPath(suffix, false).Invoke(c).ToMultiPartsA("/").noSpace(true)
-	})
-}
-
-func actionPath(fileSuffixes []string, dirOnly bool) Action {
-	return ActionCallback(func(c Context) Action {
-		folder := filepath.Dir(c.CallbackValue)
-		expandedFolder := folder
-		if strings.HasPrefix(c.CallbackValue, "~") {
-			homedir, err := os.UserHomeDir()
-			if err != nil {
-				return ActionMessage(err.Error())
-			}
-			expandedFolder = filepath.Dir(homedir + "/" + c.CallbackValue[1:])
-		}
-
-		files, err := ioutil.ReadDir(expandedFolder)
-		if err != nil {
-			return ActionMessage(err.Error())
-		}
-		if folder == "." {
-			folder = ""
-		} else if !strings.HasSuffix(folder, "/") {
-			folder = folder + "/"
-		}
-
-		showHidden := c.CallbackValue != "" &&
-			!strings.HasSuffix(c.CallbackValue, "/") &&
-			strings.HasPrefix(filepath.Base(c.CallbackValue), ".")
-
-		vals := make([]string, 0, len(files))
-		for _, file := range files {
-			if !showHidden && strings.HasPrefix(file.Name(), ".") {
-				continue
-			}
-
-			if file.IsDir() {
-				vals = append(vals, folder+file.Name()+"/")
-			} else if !dirOnly {
-				if len(fileSuffixes) == 0 {
-					fileSuffixes = []string{""}
-				}
-				for _, suffix := range fileSuffixes {
-					if strings.HasSuffix(file.Name(), suffix) {
-						vals = append(vals, folder+file.Name())
-						break
-					}
-				}
-			}
-		}
-		if strings.HasPrefix(c.CallbackValue, "./") {
-			return ActionValues(vals...).Invoke(Context{}).Prefix("./").ToA()
-		}
-		return ActionValues(vals...)
-	})
-}
-
 // ActionValues completes arbitrary keywords (values)
 func ActionValues(values ...string) Action {
 	return ActionCallback(func(c Context) Action {
@@ -130,6 +46,9 @@ func ActionValues(values ...string) Action {
 
 // ActionValuesDescribed completes arbitrary key (values) with an additional description (value, description pairs)
 func ActionValuesDescribed(values ...string) Action {
+	if invalid := invalidArgCount("ActionValuesDescribed", values, 2); invalid != "" {
+		return actionInvalid(invalid)
+	}
 	return ActionCallback(func(c Context) Action {
 		vals := make([]common.RawValue, len(values)/2)
 		for index, val := range values {
@@ -141,6 +60,73 @@ func ActionValuesDescribed(values ...string) Action {
 	})
 }
 
+// ActionStyledValues completes values with a style (value, style pairs)
+//   carapace.ActionStyledValues(
+//       "running", style.Green,
+//       "failed", style.Red,
+//   )
+func ActionStyledValues(values ...string) Action {
+	if invalid := invalidArgCount("ActionStyledValues", values, 2); invalid != "" {
+		return actionInvalid(invalid)
+	}
+	return ActionCallback(func(c Context) Action {
+		vals := make([]string, 0, len(values)/2*3)
+		for index, val := range values {
+			if index%2 == 0 {
+				vals = append(vals, val, "", values[index+1])
+			}
+		}
+		return ActionStyledValuesDescribed(vals...)
+	})
+}
+
+// ActionStyledValuesDescribed completes values with a description and a style (value, description, style triplets)
+//   carapace.ActionStyledValuesDescribed(
+//       "main", "clean branch", style.Default,
+//       "feature", "dirty branch", style.Red,
+//   )
+func ActionStyledValuesDescribed(values ...string) Action {
+	if invalid := invalidArgCount("ActionStyledValuesDescribed", values, 3); invalid != "" {
+		return actionInvalid(invalid)
+	}
+	return ActionCallback(func(c Context) Action {
+		vals := make([]common.RawValue, len(values)/3)
+		for index, val := range values {
+			if index%3 == 0 {
+				vals[index/3] = common.RawValue{Value: val, Display: val, Description: values[index+1], Style: values[index+2]}
+			}
+		}
+		return actionRawValues(vals...)
+	})
+}
+
+// invalidArgCount checks if the amount of values is a multiple of given tuple size
+func invalidArgCount(name string, values []string, size int) string {
+	if len(values)%size != 0 {
+		return fmt.Sprintf("%v expects a multiple of %v arguments (got %v)", name, size, len(values))
+	}
+	return ""
+}
+
+// actionInvalid displays given reason during completion (also reported by Test unless created within a callback)
+func actionInvalid(reason string) Action {
+	a := ActionMessage(reason)
+	a.invalid = reason
+	return a
+}
+
+// withInvalid carries the reason of given wrapped actions being invalid over (so that Test reports these as well)
+func (a Action) withInvalid(actions ...Action) Action {
+	reasons := make([]string, 0)
+	for _, action := range append([]Action{a}, actions...) {
+		if action.invalid != "" {
+			reasons = append(reasons, action.invalid)
+		}
+	}
+	a.invalid = strings.Join(reasons, ", ")
+	return a
+}
+
 func actionRawValues(rawValues ...common.RawValue) Action {
 	return Action{
 		rawValues: rawValues,
@@ -174,7 +160,7 @@ func ActionMultiParts(divider string, callback func(c Context) Action) Action {
 		}
 		c.Parts = parts
 
-		return callback(c).Invoke(c).Prefix(prefix).ToA().noSpace(true)
+		return callback(c).Invoke(c).prefixSegment(prefix).ToA().noSpace(true)
 	})
 }
 
@@ -188,7 +174,7 @@ func actionSubcommands(cmd *cobra.Command) Action {
 			}
 		}
 	}
-	return ActionValuesDescribed(vals...)
+	return ActionValuesDescribed(vals...).Tag(common.TagCommands)
 }
 
 func actionFlags(cmd *cobra.Command) Action {
@@ -233,8 +219,8 @@ func actionFlags(cmd *cobra.Command) Action {
 		})
 
 		if isShorthandSeries {
-			return ActionValuesDescribed(vals...).Invoke(c).Prefix(c.CallbackValue).ToA().noSpace(true)
+			return ActionValuesDescribed(vals...).Tag(common.TagFlags).Invoke(c).Prefix(c.CallbackValue).ToA().noSpace(true)
 		}
-		return ActionValuesDescribed(vals...)
+		return ActionValuesDescribed(vals...).Tag(common.TagFlags)
 	})
 }
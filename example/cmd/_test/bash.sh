@@ -8,6 +8,7 @@ _example_completion() {
   [[ "${COMPREPLY[*]}" == "" ]] && COMPREPLY=() # fix for mapfile creating a non-empty array from empty command output
 
   [[ ${COMPREPLY[0]} == *[/=@:.,$'\001'] ]] && compopt -o nospace
+  compopt -o nosort 2>/dev/null # keep order of values (bash 4.4+)
   # TODO use mapfile
   # shellcheck disable=SC2206
   [[ ${#COMPREPLY[@]} -eq 1 ]] && COMPREPLY=(${COMPREPLY%$'\001'})
@@ -15,5 +15,5 @@ function _example_callback
 end
 
 complete -c example -f
-complete -c 'example' -f -a '(_example_callback)' -r
+complete -c 'example' -f -k -a '(_example_callback)' -r
 
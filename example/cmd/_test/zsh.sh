@@ -2,29 +2,57 @@
 function _example_completion {
   local IFS=$'\n'
   
+  local lines
   # shellcheck disable=SC2207,SC2086,SC2154
   if echo ${words}"''" | xargs echo 2>/dev/null > /dev/null; then
     # shellcheck disable=SC2207,SC2086
-    local c=($(echo ${words}"''" | xargs example _carapace zsh _ ))
+    lines=("${(@f)$(echo ${words}"''" | xargs example _carapace zsh _ )}")
   elif echo ${words} | sed "s/\$/'/" | xargs echo 2>/dev/null > /dev/null; then
     # shellcheck disable=SC2207,SC2086
-    local c=($(echo ${words} | sed "s/\$/'/" | xargs example _carapace zsh _ ))
+    lines=("${(@f)$(echo ${words} | sed "s/\$/'/" | xargs example _carapace zsh _ )}")
   else
     # shellcheck disable=SC2207,SC2086
-    local c=($(echo ${words} | sed 's/$/"/'  | xargs example _carapace zsh _ ))
+    lines=("${(@f)$(echo ${words} | sed 's/$/"/'  | xargs example _carapace zsh _ )}")
   fi
 
-  # shellcheck disable=SC2034,2206
-  local vals=(${c%%$'\t'*})
-  # shellcheck disable=SC2034,2206
-  local descriptions=(${c##*$'\t'})
+  # first line contains the list-colors zstyle for styled values
+  if [[ -n ${lines[1]} ]]; then
+    zstyle ":completion:${curcontext}:*" list-colors "${(@ps:\t:)lines[1]}"
+  else
+    zstyle -d ":completion:${curcontext}:*" list-colors
+  fi
+  local c=("${(@)lines[2,-1]}")
 
-  local suffix=' '
-  [[ ${vals[1]} == *$'\001' ]] && suffix=''
+  # move a typed directory part containing $VAR or ~ to IPREFIX so that compadd keeps it unquoted
+  local typed=''
+  if [[ ${PREFIX} == (*\$*|\~*)/* ]]; then
+    typed="${IPREFIX}"
+    compset -P '*/'
+    typed="${IPREFIX#${(b)typed}}" # everything up to the last slash
+  fi
+
+  local tag tagged expl vals descriptions suffix
   # shellcheck disable=SC2034,2206
-  vals=(${vals%%$'\001'*})
+  local tags=(${c%%$'\t'*})
+  for tag in ${(u)tags}; do
+    # shellcheck disable=SC2034,2206
+    tagged=(${(M)c:#${(b)tag}$'\t'*})
+    # shellcheck disable=SC2034,2206
+    tagged=(${tagged#*$'\t'})
+    # shellcheck disable=SC2034,2206
+    vals=(${tagged%%$'\t'*})
+    # shellcheck disable=SC2034,2206
+    descriptions=(${tagged##*$'\t'})
+
+    suffix=' '
+    [[ ${vals[1]} == *$'\001' ]] && suffix=''
+    # shellcheck disable=SC2034,2206
+    vals=(${vals%%$'\001'*})
+    # shellcheck disable=SC2034,2206
+    vals=("${(@)vals#${(b)typed}}")
 
-  compadd -l -S "${suffix}" -d descriptions -a -- vals
+    _wanted -V "${tag}" expl "${tag}" compadd -U -l -S "${suffix}" -d descriptions -a -- vals
+  done
 }
 compquote '' 2>/dev/null && _example_completion
 compdef _example_completion example
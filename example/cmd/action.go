@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/rsteube/carapace"
 	"github.com/rsteube/carapace/example/cmd/action/net"
 	"github.com/rsteube/carapace/example/cmd/action/os"
+	"github.com/rsteube/carapace/pkg/style"
 	"github.com/spf13/cobra"
 )
 
@@ -28,13 +31,14 @@ func init() {
 	actionCmd.Flags().StringP("values", "v", "", "values flag")
 	actionCmd.Flags().StringP("values_described", "d", "", "values with description flag")
 	//actionCmd.Flags().StringS("shorthandonly", "s", "", "shorthandonly flag")
+	actionCmd.Flags().String("styled", "", "styled values flag")
 	actionCmd.Flags().StringP("kill", "k", "", "kill signals")
 	actionCmd.Flags().StringP("optarg", "o", "", "optional arg with default value blue")
 	actionCmd.Flag("optarg").NoOptDefVal = "blue"
 
 	carapace.Gen(actionCmd).FlagCompletion(carapace.ActionMap{
 		"files": carapace.ActionCallback(func(c carapace.Context) carapace.Action {
-			return carapace.ActionFiles(".go", "go.mod", ".txt").Chdir(actionCmd.Flag("directories").Value.String())
+			return carapace.ActionFiles(".go", "go.mod", ".txt").Chdir(strings.Join(c.Flags["directories"], ""))
 		}),
 		"directories":      carapace.ActionDirectories(),
 		"groups":           os.ActionGroups(),
@@ -46,6 +50,11 @@ func init() {
 		"values_described": carapace.ActionValuesDescribed("values", "valueDescription", "example", "exampleDescription"),
 		"kill":             os.ActionKillSignals(),
 		"optarg":           carapace.ActionValues("blue", "red", "green", "yellow"),
+		"styled": carapace.ActionStyledValuesDescribed(
+			"running", "service is running", style.Green,
+			"failed", "service has failed", style.Of(style.Red, style.Bold),
+			"stopped", "service is stopped", style.Default,
+		),
 	})
 
 	carapace.Gen(actionCmd).PositionalCompletion(
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/rsteube/carapace"
 	"github.com/spf13/cobra"
 )
@@ -23,10 +25,10 @@ func init() {
 
 	carapace.Gen(conditionCmd).PositionalCompletion(
 		carapace.ActionCallback(func(c carapace.Context) (result carapace.Action) {
-			if conditionCmd.Flag("required").Value.String() == "valid" {
+			if required := strings.Join(c.Flags["required"], ","); required == "valid" {
 				result = carapace.ActionValues("condition fulfilled")
 			} else {
-				result = carapace.ActionMessage("flag --required must be set to valid: " + conditionCmd.Flag("required").Value.String())
+				result = carapace.ActionMessage("flag --required must be set to valid: " + required)
 			}
 			return
 		}),
@@ -45,3 +45,7 @@ func TestXonsh(t *testing.T) {
 func TestZsh(t *testing.T) {
 	testScript(t, "zsh", "./_test/zsh.sh")
 }
+
+func TestSmoke(t *testing.T) {
+	carapace.Smoke(t, rootCmd)
+}
@@ -0,0 +1,121 @@
+package carapace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	exec "golang.org/x/sys/execabs"
+)
+
+// ExecOpts contains options for ActionExecCommandOpts
+type ExecOpts struct {
+	// Env contains additional environment variables for the command
+	//   []string{"KUBECONFIG=/tmp/kubeconfig"}
+	Env []string
+	// Dir sets the working directory of the command (relative to Context.Dir)
+	Dir string
+	// Stdin is passed to the command as standard input
+	Stdin []byte
+	// ExitCodes contains non-zero exit codes which are still considered successful
+	//   []int{1} // terraform plan -detailed-exitcode
+	ExitCodes []int
+	// MaxOutput limits the amount of bytes read from stdout (unlimited when zero).
+	// The command is stopped when it is exceeded and only complete lines are passed on (not cached).
+	// A message is shown instead when not even a single line is complete.
+	MaxOutput int
+	// OnError maps the output of stderr to an Action when the command failed (defaults to ActionMessage with its first line)
+	//   func(stderr []byte, err error) carapace.Action {
+	//       return carapace.ActionMessage("not logged in")
+	//   }
+	OnError func(stderr []byte, err error) Action
+}
+
+// ActionExecCommandOpts is like ActionExecCommand but with additional control over the command invocation
+//   carapace.ActionExecCommandOpts(carapace.ExecOpts{
+//       Env:       []string{"KUBECONFIG=" + c.Flags["kubeconfig"][0]},
+//       ExitCodes: []int{1},
+//   }, "kubectl", "get", "namespaces", "-o", "name")(func(output []byte) carapace.Action {
+//       lines := strings.Split(string(output), "\n")
+//       return carapace.ActionValues(lines[:len(lines)-1]...)
+//   })
+func ActionExecCommandOpts(execOpts ExecOpts, name string, arg ...string) func(f func(output []byte) Action) Action {
+	return func(f func(output []byte) Action) Action {
+		return ActionCallback(func(c Context) Action {
+			ctx, cancel := context.WithCancel(c)
+			defer cancel()
+
+			var stdout, stderr bytes.Buffer
+			limited := &limitedWriter{buffer: &stdout, limit: execOpts.MaxOutput, onLimit: cancel}
+			cmd := exec.CommandContext(ctx, name, arg...)
+			cmd.Dir = c.Dir
+			if execOpts.Dir != "" {
+				cmd.Dir = c.abs(execOpts.Dir)
+			}
+			cmd.Env = append(c.Environ(), execOpts.Env...)
+			if execOpts.Stdin != nil {
+				cmd.Stdin = bytes.NewReader(execOpts.Stdin)
+			}
+			cmd.Stdout = limited
+			cmd.Stderr = &stderr
+			err := cmd.Run()
+			if limited.exceeded && c.Err() == nil {
+				output := stdout.Bytes()
+				output = output[:bytes.LastIndexByte(output, '\n')+1] // drop the partial last line
+				if len(output) == 0 {
+					return ActionMessage(fmt.Sprintf("%v: output truncated at %v bytes", name, execOpts.MaxOutput))
+				}
+				return f(output).skipCache(true) // incomplete so don't cache it
+			}
+			if err != nil && !execOpts.acceptsExitCode(err) {
+				if c.Err() != nil {
+					return ActionMessage(fmt.Sprintf("%v: %v", name, c.Err()))
+				}
+				if execOpts.OnError != nil {
+					return execOpts.OnError(stderr.Bytes(), err)
+				}
+				if firstLine := strings.SplitN(stderr.String(), "\n", 2)[0]; strings.TrimSpace(firstLine) != "" {
+					return ActionMessage(stripAnsi(firstLine))
+				}
+				return ActionMessage(err.Error())
+			}
+			return f(stdout.Bytes())
+		})
+	}
+}
+
+func (o ExecOpts) acceptsExitCode(err error) bool {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		for _, code := range o.ExitCodes {
+			if exitErr.ExitCode() == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// limitedWriter discards anything exceeding limit and calls onLimit once it is exceeded (unlimited when zero)
+type limitedWriter struct {
+	buffer   *bytes.Buffer
+	limit    int
+	onLimit  func()
+	exceeded bool
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.limit > 0 {
+		if remaining := w.limit - w.buffer.Len(); remaining < len(p) {
+			if remaining > 0 {
+				w.buffer.Write(p[:remaining])
+			}
+			if !w.exceeded {
+				w.exceeded = true
+				w.onLimit() // stop the command
+			}
+			return len(p), nil // pretend to have written everything until the command is stopped
+		}
+	}
+	return w.buffer.Write(p)
+}
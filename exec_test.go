@@ -0,0 +1,70 @@
+package carapace
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActionExecCommandOpts(t *testing.T) {
+	echo := func(output []byte) Action { return ActionValues(string(output)) }
+
+	assertEqual(t,
+		ActionValues("value\n").Invoke(Context{}),
+		ActionExecCommandOpts(ExecOpts{Env: []string{"CARAPACE_TEST=value"}}, "sh", "-c", "echo $CARAPACE_TEST")(echo).Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionValues("action.go\nsnippet.go\n").Invoke(Context{}),
+		ActionExecCommandOpts(ExecOpts{Dir: "elvish"}, "ls")(echo).Chdir("internal").Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionValues("from stdin").Invoke(Context{}),
+		ActionExecCommandOpts(ExecOpts{Stdin: []byte("from stdin")}, "cat")(echo).Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionMessage("exit status 1").Invoke(Context{}),
+		ActionExecCommandOpts(ExecOpts{}, "sh", "-c", "echo partial; exit 1")(echo).Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionValues("partial\n").Invoke(Context{}),
+		ActionExecCommandOpts(ExecOpts{ExitCodes: []int{1}}, "sh", "-c", "echo partial; exit 1")(echo).Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionValues("from context\n").Invoke(Context{}),
+		ActionExecCommandOpts(ExecOpts{}, "sh", "-c", "echo $CARAPACE_TEST")(echo).Invoke(Context{Env: map[string]string{"CARAPACE_TEST": "from context"}}),
+	)
+
+	assertEqual(t,
+		ActionMessage("sh: output truncated at 4 bytes").Invoke(Context{}),
+		ActionExecCommandOpts(ExecOpts{MaxOutput: 4}, "sh", "-c", "echo 0123456789; echo 0123456789")(echo).Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionValues("0123456789\n").skipCache(true).Invoke(Context{}),
+		ActionExecCommandOpts(ExecOpts{MaxOutput: 15}, "sh", "-c", "echo 0123456789; echo 0123456789")(echo).Invoke(Context{}),
+	)
+
+	assertEqual(t,
+		ActionMessage("mapped: not logged in").Invoke(Context{}),
+		ActionExecCommandOpts(ExecOpts{
+			OnError: func(stderr []byte, err error) Action {
+				return ActionMessage("mapped: " + string(stderr))
+			},
+		}, "sh", "-c", "printf 'not logged in' >&2; exit 2")(echo).Invoke(Context{}),
+	)
+}
+
+func TestActionExecCommandOptsMaxOutputStops(t *testing.T) {
+	start := time.Now()
+	ActionExecCommandOpts(ExecOpts{MaxOutput: 4}, "sh", "-c", "echo 0123456789; exec sleep 10")(func(output []byte) Action {
+		return ActionValues()
+	}).Invoke(Context{})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("command should have been stopped after exceeding MaxOutput [took: %v]", elapsed)
+	}
+}
@@ -0,0 +1,264 @@
+package carapace
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/internal/fs"
+	"github.com/rsteube/carapace/pkg/style"
+)
+
+// FileOpts contains options for ActionFilesOpts
+type FileOpts struct {
+	// Patterns restricts files to given glob patterns (brace expressions are supported)
+	//   []string{"*.tar.{gz,xz}", "*.zip"}
+	Patterns []string
+	// Exclude hides files and directories matching given glob patterns
+	//   []string{"node_modules", "*.o"}
+	Exclude []string
+	// Gitignore hides files and directories ignored by `.gitignore` and `.ignore` files
+	Gitignore bool
+	// Executable restricts files to executables
+	Executable bool
+}
+
+// ActionDirectories completes directories
+func ActionDirectories() Action {
+	return ActionCallback(func(c Context) Action {
+		return actionPath(FileOpts{}, true).Invoke(c).ToMultiPartsA("/").noSpace(true)
+	})
+}
+
+// ActionDirectoriesRoots completes directories within given search roots (uses CDPATH if none are given)
+// The root is shown as description and takes precedence in given order for directories existing in multiple roots.
+//   carapace.ActionDirectoriesRoots("~/work", "~/oss")
+func ActionDirectoriesRoots(roots ...string) Action {
+	return ActionCallback(func(c Context) Action {
+		searchRoots := roots
+		if len(searchRoots) == 0 {
+			searchRoots = filepath.SplitList(c.Getenv("CDPATH"))
+		}
+
+		actions := make([]Action, 0, len(searchRoots))
+		for index := len(searchRoots) - 1; index >= 0; index-- { // merged in reverse so that earlier roots take precedence
+			actions = append(actions, actionDirectoriesRoot(searchRoots[index]))
+		}
+		return Batch(actions...).ToA()
+	})
+}
+
+func actionDirectoriesRoot(root string) Action {
+	if root == "" {
+		root = "." // empty CDPATH entry refers to the working directory
+	}
+	return ActionCallback(func(c Context) Action {
+		expanded, err := expandPath(c, root)
+		if err != nil {
+			return ActionValues() // skip unresolvable roots
+		}
+		folder, err := expandPath(c, filepath.Dir(c.CallbackValue))
+		if err != nil {
+			return ActionValues()
+		}
+		if !filepath.IsAbs(folder) {
+			folder = filepath.Join(c.abs(expanded), folder)
+		}
+		if info, err := os.Stat(folder); err != nil || !info.IsDir() {
+			return ActionValues() // skip roots missing the typed directory
+		}
+
+		invoked := ActionDirectories().Chdir(root).Invoke(c)
+		for index, rawValue := range invoked.rawValues {
+			if rawValue.Display != "ERR" && rawValue.Display != "_" { // keep messages intact
+				invoked.rawValues[index].Description = root
+			}
+		}
+		return invoked.ToA()
+	})
+}
+
+// ActionFiles completes files with optional suffix filtering
+func ActionFiles(suffix ...string) Action {
+	patterns := make([]string, 0, len(suffix))
+	for _, s := range suffix {
+		patterns = append(patterns, "*"+escapeGlob(s))
+	}
+	return ActionFilesOpts(FileOpts{Patterns: patterns})
+}
+
+// ActionFilesOpts completes files with given options
+//   carapace.ActionFilesOpts(carapace.FileOpts{
+//       Patterns:  []string{"*.{yaml,yml}"},
+//       Exclude:   []string{"node_modules"},
+//       Gitignore: true,
+//   })
+func ActionFilesOpts(fileOpts FileOpts) Action {
+	return ActionCallback(func(c Context) Action {
+		return actionPath(fileOpts, false).Invoke(c).ToMultiPartsA("/").noSpace(true)
+	})
+}
+
+func escapeGlob(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		"*", `\*`,
+		"?", `\?`,
+		"[", `\[`,
+		"{", `\{`,
+	).Replace(s)
+}
+
+func actionPath(fileOpts FileOpts, dirOnly bool) Action {
+	return ActionCallback(func(c Context) Action {
+		folder := filepath.Dir(c.CallbackValue)
+		expandedFolder, err := expandPath(c, folder) // only used for listing so that values keep the typed prefix
+		if err != nil {
+			return ActionMessage(err.Error())
+		}
+		absFolder := c.abs(expandedFolder)
+
+		names, err := readDirNames(absFolder)
+		if err != nil {
+			return ActionMessage(err.Error())
+		}
+		if folder == "." {
+			folder = ""
+		} else if !strings.HasSuffix(folder, "/") {
+			folder = folder + "/"
+		}
+
+		showHidden := c.CallbackValue != "" &&
+			!strings.HasSuffix(c.CallbackValue, "/") &&
+			strings.HasPrefix(filepath.Base(c.CallbackValue), ".")
+
+		var lsColors *style.LsColors
+		if value := c.Getenv("LS_COLORS"); value != "" {
+			parsed := style.ParseLsColors(value) // parsed once per invocation
+			lsColors = &parsed
+		}
+
+		var ignore fs.Ignore
+		if fileOpts.Gitignore {
+			ignore = fs.LoadIgnore(absFolder)
+		}
+
+		vals := make([]common.RawValue, 0, len(names))
+		for _, name := range names {
+			if !showHidden && strings.HasPrefix(name, ".") {
+				continue
+			}
+			if fs.MatchAny(name, fileOpts.Exclude...) {
+				continue
+			}
+
+			path := filepath.Join(absFolder, name)
+			file, err := os.Stat(path) // follow symlinks
+			if err != nil {
+				if file, err = os.Lstat(path); err != nil { // surface the error for this entry only
+					vals = append(vals, common.RawValue{Value: folder + name, Display: folder + name, Description: err.Error(), Tag: common.TagFiles})
+					continue
+				}
+			}
+
+			if fileOpts.Gitignore && ignore.Match(path, file.IsDir()) {
+				continue
+			}
+
+			if file.IsDir() {
+				rawValue := common.RawValue{Value: folder + name + "/", Display: folder + name + "/", Tag: common.TagDirectories}
+				if dir, err := os.Open(path); err != nil {
+					if os.IsPermission(err) {
+						rawValue.Description = "permission denied"
+					}
+				} else {
+					dir.Close()
+				}
+				if lsColors != nil {
+					rawValue.Style = lsColors.ForPath(path)
+				}
+				vals = append(vals, rawValue)
+			} else if !dirOnly {
+				if len(fileOpts.Patterns) > 0 && !fs.MatchAny(name, fileOpts.Patterns...) {
+					continue
+				}
+				if fileOpts.Executable && file.Mode()&0111 == 0 {
+					continue
+				}
+				rawValue := common.RawValue{Value: folder + name, Display: folder + name, Tag: common.TagFiles}
+				if lsColors != nil {
+					rawValue.Style = lsColors.ForPath(path)
+				}
+				vals = append(vals, rawValue)
+			}
+		}
+		if strings.HasPrefix(c.CallbackValue, "./") {
+			return actionRawValues(vals...).Invoke(Context{}).Prefix("./").ToA()
+		}
+		return actionRawValues(vals...)
+	})
+}
+
+// expandPath expands environment variables and a leading `~` or `~user` (unset variables are an error)
+//   expandPath(c, "$HOME/.config")  // "/home/user/.config"
+//   expandPath(c, "~alice/.config") // "/home/alice/.config"
+func expandPath(c Context, path string) (string, error) {
+	unset := make([]string, 0)
+	path = os.Expand(path, func(key string) string {
+		value, ok := c.lookupEnv(key)
+		if !ok {
+			unset = append(unset, key)
+		}
+		return value
+	})
+	if len(unset) > 0 {
+		return "", fmt.Errorf("environment variable not set: %v", strings.Join(unset, ", "))
+	}
+
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+
+	name := strings.SplitN(path[1:], "/", 2)[0]
+	var home string
+	if name == "" {
+		env := "HOME" // same variables as os.UserHomeDir but from Context.Env
+		switch runtime.GOOS {
+		case "windows":
+			env = "USERPROFILE"
+		case "plan9":
+			env = "home"
+		}
+		if home = c.Getenv(env); home == "" {
+			return "", fmt.Errorf("$%v is not defined", env)
+		}
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		home = u.HomeDir
+	}
+	return home + path[1+len(name):], nil
+}
+
+// readDirNames reads the sorted names of the entries in given directory
+func readDirNames(dir string) ([]string, error) {
+	file, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	names, err := file.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
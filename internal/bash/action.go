@@ -38,6 +38,34 @@ var quoter = strings.NewReplacer(
 	`\`, `\\`,
 )
 
+// expansionQuoter is like quoter but keeps environment variables intact
+var expansionQuoter = strings.NewReplacer(
+	`&`, `\&`,
+	`<`, `\<`,
+	`>`, `\>`,
+	"`", "\\`",
+	`'`, `\'`,
+	`"`, `\"`,
+	`#`, `\#`,
+	`|`, `\|`,
+	`?`, `\?`,
+	`(`, `\(`,
+	`)`, `\)`,
+	`;`, `\;`,
+	` `, `\ `,
+	`[`, `\[`,
+	`]`, `\]`,
+	`*`, `\*`,
+	`\`, `\\`,
+)
+
+// quote quotes given value but keeps environment variables and `~` within the directory part typed by the user
+//   quote("$HOME/my file", "$HOME/my") // $HOME/my\ file
+func quote(value, typed string) string {
+	prefix := common.ExpansionPrefix(value, typed)
+	return expansionQuoter.Replace(prefix) + quoter.Replace(value[len(prefix):])
+}
+
 func commonPrefix(a, b string) string {
 	i := 0
 	for i < len(a) && i < len(b) && a[i] == b[i] {
@@ -77,22 +105,20 @@ func ActionRawValues(currentWord string, nospace bool, values common.RawValues)
 	lastSegment := currentWord // last segment of currentWord split by COMP_WORDBREAKS
 
 	for _, r := range values {
-		if strings.HasPrefix(r.Value, currentWord) {
-			// TODO optimize
-			if wordbreaks, ok := os.LookupEnv("COMP_WORDBREAKS"); ok {
-				wordbreaks = strings.Replace(wordbreaks, " ", "", -1)
-				if index := strings.LastIndexAny(currentWord, wordbreaks); index != -1 {
-					r.Value = strings.TrimPrefix(r.Value, currentWord[:index+1])
-					lastSegment = currentWord[index+1:]
-				}
+		// TODO optimize
+		if wordbreaks, ok := os.LookupEnv("COMP_WORDBREAKS"); ok {
+			wordbreaks = strings.Replace(wordbreaks, " ", "", -1)
+			if index := strings.LastIndexAny(currentWord, wordbreaks); index != -1 {
+				r.Value = strings.TrimPrefix(r.Value, currentWord[:index+1])
+				lastSegment = currentWord[index+1:]
 			}
-			filtered = append(filtered, r)
 		}
+		filtered = append(filtered, r)
 	}
 
 	if len(filtered) > 1 && commonDisplayPrefix(filtered...) != "" {
 		// When all display values have the same prefix bash will insert is as partial completion (which skips prefixes/formatting).
-		if valuePrefix := commonValuePrefix(filtered...); lastSegment != valuePrefix {
+		if valuePrefix := commonValuePrefix(filtered...); lastSegment != valuePrefix && strings.HasPrefix(valuePrefix, lastSegment) { // values might not start with the current word (e.g. substring matching)
 			// replace values with common value prefix (`\001` is removed in snippet and compopt nospace will be set)
 			filtered = common.RawValuesFrom(commonValuePrefix(filtered...) + nospaceIndicator)
 		} else {
@@ -108,7 +134,7 @@ func ActionRawValues(currentWord string, nospace bool, values common.RawValues)
 		}
 
 		if len(filtered) == 1 {
-			vals[index] = quoter.Replace(sanitizer.Replace(val.Value))
+			vals[index] = quote(sanitizer.Replace(val.Value), lastSegment)
 		} else {
 			if val.Description != "" {
 				vals[index] = fmt.Sprintf("%v (%v)", val.Display, sanitizer.Replace(val.TrimmedDescription()))
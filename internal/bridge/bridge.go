@@ -0,0 +1,43 @@
+// Package bridge provides scripts to invoke completions of other shells
+package bridge
+
+// Bash invokes the completion registered with `complete` for given words (command line) and prints the candidates.
+// bash-completion and its lazy loading are used when available.
+const Bash = `for file in /usr/share/bash-completion/bash_completion /etc/bash_completion; do
+  [ -f "$file" ] && . "$file" && break
+done
+
+COMP_WORDS=("$@")
+COMP_CWORD=$((${#COMP_WORDS[@]} - 1))
+COMP_LINE="${COMP_WORDS[*]}"
+COMP_POINT=${#COMP_LINE}
+cmd="${COMP_WORDS[0]}"
+
+if ! complete -p "$cmd" >/dev/null 2>&1; then
+  if declare -F __load_completion >/dev/null; then
+    __load_completion "$cmd"
+  elif declare -F _completion_loader >/dev/null; then
+    _completion_loader "$cmd"
+  fi
+fi
+if ! complete -p "$cmd" >/dev/null 2>&1; then
+  for dir in "${BASH_COMPLETION_USER_DIR:-${XDG_DATA_HOME:-$HOME/.local/share}/bash-completion}/completions" /usr/share/bash-completion/completions; do
+    for file in "$dir/$cmd" "$dir/$cmd.bash" "$dir/_$cmd"; do
+      [ -f "$file" ] && . "$file" >/dev/null 2>&1 && break 2
+    done
+  done
+fi
+
+spec="$(complete -p "$cmd" 2>/dev/null)" || exit 0
+spec="${spec#complete }"
+spec="${spec% *}"
+if [[ "$spec" =~ -F\ ([^ ]+) ]]; then
+  "${BASH_REMATCH[1]}" "$cmd" "${COMP_WORDS[COMP_CWORD]}" "${COMP_WORDS[COMP_CWORD-1]}" >/dev/null 2>&1
+  printf '%s\n' "${COMPREPLY[@]}"
+else
+  eval "compgen $spec -- \"\${COMP_WORDS[COMP_CWORD]}\""
+fi
+`
+
+// Fish prints the candidates for the command line passed with CARAPACE_COMPLINE.
+const Fish = `complete --do-complete="$CARAPACE_COMPLINE"`
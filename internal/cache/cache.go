@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/user"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -17,58 +17,117 @@ import (
 	"github.com/rsteube/carapace/pkg/cache"
 )
 
-// Write persistests given values to file as json
-func Write(file string, rawValues []common.RawValue) (err error) {
+// Version of the cache format (entries of other versions are treated as missing)
+const Version = 1
+
+// Entry contains the values and metadata of a cached Action
+type Entry struct {
+	Version           int
+	RawValues         []common.RawValue
+	Nospace           bool
+	KeepOrder         bool
+	MatchDescriptions bool
+	Segment           string        `json:",omitempty"` // prefix skipped by the matcher (see ActionMultiParts)
+	Caller            string        `json:",omitempty"` // location Cache() was called from (`file:line`)
+	Keys              []string      `json:",omitempty"`
+	Timeout           time.Duration `json:",omitempty"` // used to remove expired entries
+	Stale             bool          `json:",omitempty"` // expired entries are still used (thus only evicted)
+}
+
+// Write persistests given entry to the cache as json
+func Write(backend cache.Cache, id string, e Entry) (err error) {
+	e.Version = Version
 	var m []byte
-	if m, err = json.Marshal(rawValues); err == nil {
-		err = ioutil.WriteFile(file, m, 0600)
+	if m, err = json.Marshal(e); err == nil {
+		err = backend.Write(id, m)
 	}
 	return
 }
 
-// Load loads values from file unless modification date exceeds timeout
-func Load(file string, timeout time.Duration) (rawValues []common.RawValue, err error) {
-	var content []byte
-	var stat os.FileInfo
-	if stat, err = os.Stat(file); os.IsNotExist(err) || (timeout > 0 && stat.ModTime().Add(timeout).Before(time.Now())) {
-		err = errors.New("not exists or timeout exceeded")
-	} else {
-		if content, err = ioutil.ReadFile(file); err == nil {
-			err = json.Unmarshal(content, &rawValues)
+// Load loads an entry from the cache unless modification date exceeds timeout (marks it as recently used at most once per GCInterval)
+func Load(backend cache.Cache, id string, timeout time.Duration) (e Entry, err error) {
+	var entry cache.Entry
+	if entry, err = backend.Load(id); err == nil {
+		if timeout > 0 && entry.ModTime.Add(timeout).Before(time.Now()) {
+			err = errors.New("timeout exceeded")
+		} else if err = decode(entry.Content, &e); err == nil {
+			touch(backend, id, entry.AccessTime)
 		}
 	}
 	return
 }
 
-// TempDir creates a temporary folder for current user and returns the path
-func TempDir(name string) (dir string, err error) {
-	var u *user.User
-	if u, err = user.Current(); err == nil {
-		dir = fmt.Sprintf("%v/carapace", os.TempDir())
-		if err = os.MkdirAll(dir, 0777); err == nil {
-			dir = fmt.Sprintf("%v/%v/%v/%v", dir, u.Username, uid.Executable(), name)
-			err = os.MkdirAll(dir, 0700)
-		}
+func decode(content []byte, e *Entry) (err error) {
+	if err = json.Unmarshal(content, e); err == nil && e.Version != Version {
+		err = fmt.Errorf("outdated cache format: %v", e.Version) // also the case for entries predating the versioned format
+	}
+	return
+}
+
+// DirEnv overrides the cache root directory
+const DirEnv = "CARAPACE_CACHE_DIR"
+
+// Default returns the default file backend within the cache directory of the current executable
+func Default() (cache.Cache, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewFileCache(dir), nil
+}
+
+// Dir creates the cache folder for the current executable and returns the path
+//   $CARAPACE_CACHE_DIR/{binary}
+//   $XDG_CACHE_HOME/carapace/{binary}
+//   {os.UserCacheDir}/carapace/{binary}
+//   {os.TempDir}/carapace/{username}/{binary} // fallback
+func Dir() (dir string, err error) {
+	var root string
+	if root, err = rootDir(); err == nil {
+		dir = filepath.Join(root, uid.Executable())
+		err = os.MkdirAll(dir, 0700)
 	}
 	return
 }
 
-// File returns the cache filename for given values
-// TODO cleanup
-func File(callerFile string, callerLine int, keys ...cache.Key) (file string, err error) {
-	uid := uidKeys(callerFile, strconv.Itoa(callerLine))
+func rootDir() (string, error) {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "carapace"), nil
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "carapace"), nil
+	}
+
+	u, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(os.TempDir(), "carapace")
+	if err := os.MkdirAll(dir, 0777); err != nil { // shared by all users
+		return "", err
+	}
+	return filepath.Join(dir, u.Username), nil
+}
+
+// Keys resolves given keys
+func Keys(keys ...cache.Key) ([]string, error) {
 	ids := make([]string, 0)
 	for _, key := range keys {
 		id, err := key()
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		ids = append(ids, id)
 	}
-	if dir, err := TempDir(uid); err == nil {
-		file = dir + "/" + uidKeys(ids...)
-	}
-	return
+	return ids, nil
+}
+
+// ID returns the cache id (`{callerUid}/{keysUid}`) for given caller and resolved keys
+func ID(callerFile string, callerLine int, keys []string) string {
+	return uidKeys(callerFile, strconv.Itoa(callerLine)) + "/" + uidKeys(keys...)
 }
 
 func uidKeys(keys ...string) string {
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rsteube/carapace/pkg/cache"
+)
+
+// DefaultMaxSize is the maximum total size of all entries unless overridden
+const DefaultMaxSize = 100 * 1024 * 1024
+
+// GCInterval is the minimum duration between cleanups
+const GCInterval = time.Hour
+
+// gcMarker is the id of the entry recording the last cleanup
+const gcMarker = ".gc"
+
+// hidden checks if given id is used internally (not a cached action)
+func hidden(id string) bool {
+	return strings.HasPrefix(id, ".")
+}
+
+// listEntries returns all entries without content (loaded if the backend is no cache.Tracker)
+func listEntries(backend cache.Cache) ([]cache.Entry, error) {
+	if tracker, ok := backend.(cache.Tracker); ok {
+		return tracker.Entries("")
+	}
+
+	ids, err := backend.List("")
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]cache.Entry, 0, len(ids))
+	for _, id := range ids {
+		entry, err := backend.Load(id)
+		if err != nil {
+			continue // removed in the meantime
+		}
+		if entry.Size == 0 {
+			entry.Size = int64(len(entry.Content))
+		}
+		if entry.AccessTime.IsZero() {
+			entry.AccessTime = entry.ModTime
+		}
+		entry.Content = nil
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// touch marks given entry as recently used unless already done within GCInterval (avoids a write on every hit)
+func touch(backend cache.Cache, id string, accessTime time.Time) {
+	if tracker, ok := backend.(cache.Tracker); ok && time.Since(accessTime) > GCInterval {
+		_ = tracker.Touch(id)
+	}
+}
+
+// GC removes expired, outdated and corrupt entries and evicts the least recently used ones exceeding maxSize.
+// Does nothing if the last cleanup was less than GCInterval ago.
+func GC(backend cache.Cache, maxSize int64) error {
+	if marker, err := backend.Load(gcMarker); err == nil && time.Since(marker.ModTime) < GCInterval {
+		return nil
+	}
+	if err := backend.Write(gcMarker, []byte{}); err != nil {
+		return err
+	}
+
+	entries, err := listEntries(backend)
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]cache.Entry, 0, len(entries))
+	for _, listed := range entries {
+		if hidden(listed.ID) {
+			continue
+		}
+		if removable(backend, listed.ID) {
+			if err := backend.Delete(listed.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		remaining = append(remaining, listed)
+	}
+	return evict(backend, remaining, maxSize)
+}
+
+// removable checks if given entry is expired, outdated or corrupt
+func removable(backend cache.Cache, id string) bool {
+	entry, err := backend.Load(id)
+	if err != nil {
+		return false // removed in the meantime
+	}
+	var e Entry
+	if err := decode(entry.Content, &e); err != nil {
+		return true
+	}
+	return !e.Stale && e.Timeout > 0 && entry.ModTime.Add(e.Timeout).Before(time.Now())
+}
+
+// Evict removes the least recently used entries until their total size no longer exceeds maxSize
+func Evict(backend cache.Cache, maxSize int64) error {
+	entries, err := listEntries(backend)
+	if err != nil {
+		return err
+	}
+	visible := make([]cache.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if !hidden(entry.ID) {
+			visible = append(visible, entry)
+		}
+	}
+	return evict(backend, visible, maxSize)
+}
+
+func evict(backend cache.Cache, entries []cache.Entry, maxSize int64) error {
+	var size int64
+	for _, entry := range entries {
+		size += entry.Size
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].AccessTime.Before(entries[j].AccessTime) })
+	for _, entry := range entries {
+		if size <= maxSize {
+			break
+		}
+		if err := backend.Delete(entry.ID); err != nil {
+			return err
+		}
+		size -= entry.Size
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rsteube/carapace/pkg/cache"
+)
+
+func ids(t *testing.T, backend cache.Cache) string {
+	ids, err := backend.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprint(ids)
+}
+
+// untracked hides the optional interfaces of the wrapped backend
+type untracked struct {
+	cache.Cache
+}
+
+func TestGC(t *testing.T) {
+	backend := cache.NewMemoryCache()
+	_ = Write(backend, "valid/a", Entry{Timeout: time.Hour})
+	_ = Write(backend, "expired/a", Entry{Timeout: time.Nanosecond})
+	_ = Write(backend, "stale/a", Entry{Timeout: time.Nanosecond, Stale: true})
+	_ = backend.Write("outdated/a", []byte(`[{"Value":"outdated"}]`))
+	_ = backend.Write("corrupt/a", []byte(`{`))
+	time.Sleep(time.Millisecond)
+
+	if err := GC(backend, DefaultMaxSize); err != nil {
+		t.Fatal(err)
+	}
+	if actual := ids(t, backend); actual != "[.gc stale/a valid/a]" {
+		t.Errorf("unexpected entries: %v", actual)
+	}
+
+	_ = backend.Write("corrupt/a", []byte(`{`))
+	if err := GC(backend, DefaultMaxSize); err != nil {
+		t.Fatal(err)
+	}
+	if actual := ids(t, backend); actual != "[.gc corrupt/a stale/a valid/a]" {
+		t.Errorf("expected cleanup to be throttled: %v", actual)
+	}
+}
+
+func TestEvict(t *testing.T) {
+	backend := cache.NewMemoryCache()
+	for _, id := range []string{"a", "b", "c"} {
+		_ = backend.Write(id, make([]byte, 10))
+		time.Sleep(time.Millisecond)
+	}
+	_ = backend.Touch("a")
+
+	if err := Evict(backend, 20); err != nil {
+		t.Fatal(err)
+	}
+	if actual := ids(t, backend); actual != "[a c]" {
+		t.Errorf("expected least recently used entry to be evicted: %v", actual)
+	}
+
+	if err := Evict(backend, 0); err != nil {
+		t.Fatal(err)
+	}
+	if actual := ids(t, backend); actual != "[]" {
+		t.Errorf("expected all entries to be evicted: %v", actual)
+	}
+}
+
+func TestEvictUntracked(t *testing.T) {
+	backend := untracked{cache.NewMemoryCache()}
+	for _, id := range []string{"a", "b", "c"} {
+		_ = backend.Write(id, make([]byte, 10))
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := Evict(backend, 20); err != nil {
+		t.Fatal(err)
+	}
+	if actual := ids(t, backend); actual != "[b c]" {
+		t.Errorf("expected oldest entry to be evicted: %v", actual)
+	}
+}
+
+func TestLoadTouch(t *testing.T) {
+	backend := cache.NewMemoryCache()
+	_ = Write(backend, "a", Entry{})
+	written, _ := backend.Load("a")
+
+	if _, err := Load(backend, "a", 0); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, _ := backend.Load("a"); !loaded.AccessTime.Equal(written.AccessTime) {
+		t.Errorf("expected recently used entry not to be touched again: %v", loaded.AccessTime)
+	}
+}
+
+func TestDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "carapace-cachedir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(root, "xdg"))
+	if dir, err := Dir(); err != nil || filepath.Dir(dir) != filepath.Join(root, "xdg", "carapace") {
+		t.Errorf("unexpected dir: %v %v", dir, err)
+	}
+
+	os.Setenv(DirEnv, filepath.Join(root, "custom"))
+	defer os.Unsetenv(DirEnv)
+	if dir, err := Dir(); err != nil || filepath.Dir(dir) != filepath.Join(root, "custom") {
+		t.Errorf("unexpected dir: %v %v", dir, err)
+	} else if _, err := os.Stat(dir); err != nil {
+		t.Error(err)
+	}
+}
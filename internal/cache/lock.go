@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/rsteube/carapace/pkg/cache"
+)
+
+// lockInterval is the delay between attempts to acquire a lock
+const lockInterval = 10 * time.Millisecond
+
+// Lock acquires the lock for given id if supported by the backend (retries until acquired or ctx is done)
+func Lock(ctx context.Context, backend cache.Cache, id string) (unlock func(), err error) {
+	locker, ok := backend.(cache.Locker)
+	if !ok {
+		return func() {}, nil
+	}
+	for {
+		if unlock, ok, err = locker.TryLock(id); err != nil || ok {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockInterval):
+		}
+	}
+}
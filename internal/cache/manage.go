@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/rsteube/carapace/pkg/cache"
+)
+
+// Info describes a cache entry
+type Info struct {
+	ID      string
+	UID     string // uid of the location Cache() was called from
+	Caller  string
+	Keys    []string
+	Size    int64
+	ModTime time.Time
+}
+
+// List returns information about the entries matching given uid (all if empty)
+// The uid can either be the checksum (or a prefix of it) or the caller (`file:line` or a suffix of it).
+func List(backend cache.Cache, uid string) ([]Info, error) {
+	ids, err := backend.List("")
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]Info, 0, len(ids))
+	for _, id := range ids {
+		if hidden(id) {
+			continue
+		}
+		entry, err := backend.Load(id)
+		if err != nil {
+			continue // removed in the meantime
+		}
+
+		info := Info{
+			ID:      entry.ID,
+			UID:     strings.SplitN(entry.ID, "/", 2)[0],
+			Size:    entry.Size,
+			ModTime: entry.ModTime,
+		}
+		var e Entry
+		if json.Unmarshal(entry.Content, &e) == nil {
+			info.Caller = e.Caller
+			info.Keys = e.Keys
+		}
+
+		if uid == "" ||
+			strings.HasPrefix(info.UID, uid) ||
+			(info.Caller != "" && strings.HasSuffix(info.Caller, uid)) {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// Clear removes the entries matching given uid (all if empty) and returns the amount removed
+func Clear(backend cache.Cache, uid string) (int, error) {
+	infos, err := List(backend, uid)
+	if err != nil {
+		return 0, err
+	}
+	for index, info := range infos {
+		if err := backend.Delete(info.ID); err != nil {
+			return index, err
+		}
+	}
+	return len(infos), nil
+}
@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"os"
+
+	exec "golang.org/x/sys/execabs"
+)
+
+// RefreshEnv contains the id of the entry to be refreshed by a detached process
+const RefreshEnv = "CARAPACE_CACHE_REFRESH"
+
+// IsRefresh checks if the current process refreshes given id
+func IsRefresh(id string) bool {
+	return os.Getenv(RefreshEnv) == id
+}
+
+// IsRefreshProcess checks if the current process is a detached refresh
+func IsRefreshProcess() bool {
+	return os.Getenv(RefreshEnv) != ""
+}
+
+// Detach repeats the current invocation in a detached background process which refreshes given id
+func Detach(id string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), RefreshEnv+"="+id)
+	cmd.SysProcAttr = detachedProcAttr()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
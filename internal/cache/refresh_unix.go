@@ -0,0 +1,9 @@
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package cache
+
+import "syscall"
+
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true} // survive the completing shell
+}
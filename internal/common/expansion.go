@@ -0,0 +1,16 @@
+package common
+
+import "strings"
+
+// ExpansionPrefix returns the directory part typed by the user if it contains an environment variable
+// or starts with `~` (shells need to keep it unquoted for the expansion to happen on execution)
+//   ExpansionPrefix("$HOME/my file", "$HOME/my") // "$HOME/"
+//   ExpansionPrefix("/tmp/my file", "/tmp/my")   // ""
+func ExpansionPrefix(value, typed string) string {
+	if (strings.Contains(typed, "$") || strings.HasPrefix(typed, "~")) && strings.HasPrefix(value, typed) {
+		if index := strings.LastIndex(typed, "/"); index != -1 {
+			return value[:index+1]
+		}
+	}
+	return ""
+}
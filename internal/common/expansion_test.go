@@ -0,0 +1,26 @@
+package common
+
+import "testing"
+
+func TestExpansionPrefix(t *testing.T) {
+	tests := []struct {
+		value    string
+		typed    string
+		expected string
+	}{
+		{"$HOME/my file", "$HOME/my", "$HOME/"},
+		{"$HOME/dir/sub/my file", "$HOME/dir/sub/my", "$HOME/dir/sub/"},
+		{"${XDG_CONFIG_HOME}/nvim/init.lua", "${XDG_CONFIG_HOME}/nvim/", "${XDG_CONFIG_HOME}/nvim/"},
+		{"~/my file", "~/", "~/"},
+		{"~alice/my file", "~alice/m", "~alice/"},
+		{"$HOME", "$HO", ""},
+		{"/tmp/my file", "/tmp/my", ""},
+		{"a$b/file", "other", ""},
+	}
+
+	for _, test := range tests {
+		if actual := ExpansionPrefix(test.value, test.typed); actual != test.expected {
+			t.Errorf("expected %#v for %#v typed as %#v [actual: %#v]", test.expected, test.value, test.typed, actual)
+		}
+	}
+}
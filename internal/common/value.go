@@ -2,11 +2,30 @@ package common
 
 import "strings"
 
+// Tags of values created by built-in actions (mapped to shell-specific result types where possible)
+const (
+	TagCommands    = "commands"
+	TagDirectories = "directories"
+	TagFiles       = "files"
+	TagFlags       = "flags"
+	TagValues      = "values"
+)
+
 // RawValue represents a completion candidate
 type RawValue struct {
 	Value       string
 	Display     string
 	Description string
+	Style       string
+	Tag         string
+}
+
+// TagOrDefault returns the tag or `values` if none is set
+func (r RawValue) TagOrDefault() string {
+	if r.Tag == "" {
+		return TagValues
+	}
+	return r.Tag
 }
 
 // TrimmedDescription returns the trimmed description
@@ -32,38 +51,23 @@ func RawValuesFrom(values ...string) RawValues {
 	return rawValues
 }
 
-// FilterPrefix filters values with given prefix
-func (r RawValues) FilterPrefix(prefix string) RawValues {
-	filtered := make(RawValues, 0)
-	for _, r := range r {
-		if strings.HasPrefix(r.Value, prefix) {
-			filtered = append(filtered, r)
-		}
-	}
-	return filtered
-}
-
-// ByValue alias to filter by value
+// ByValue alias to sort by value
 type ByValue []RawValue
 
 func (a ByValue) Len() int           { return len(a) }
 func (a ByValue) Less(i, j int) bool { return a[i].Value < a[j].Value }
 func (a ByValue) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 
-// Filter filters values with given prefix
-func (a ByValue) Filter(prefix string) []RawValue {
-	filtered := make([]RawValue, 0, len(a))
-	for _, v := range a {
-		if strings.HasPrefix(v.Value, prefix) {
-			filtered = append(filtered, v)
-		}
-	}
-	return filtered
-}
-
-// ByDisplay alias to filter by display
+// ByDisplay alias to sort by display
 type ByDisplay []RawValue
 
 func (a ByDisplay) Len() int           { return len(a) }
 func (a ByDisplay) Less(i, j int) bool { return a[i].Display < a[j].Display }
 func (a ByDisplay) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// ByTag alias to sort by tag
+type ByTag []RawValue
+
+func (a ByTag) Len() int           { return len(a) }
+func (a ByTag) Less(i, j int) bool { return a[i].TagOrDefault() < a[j].TagOrDefault() }
+func (a ByTag) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
@@ -26,6 +26,7 @@ type complexCandidate struct {
 	Value      string
 	Display    string
 	CodeSuffix string
+	Style      string
 }
 
 // ActionRawValues formats values for elvish
@@ -39,9 +40,9 @@ func ActionRawValues(currentWord string, nospace bool, values common.RawValues)
 	for index, val := range sanitize(values) {
 		// TODO have a look at this again later: seems elvish does a good job quoting any problematic characterS so the sanitize step was removed
 		if val.Description == "" {
-			vals[index] = complexCandidate{Value: val.Value, Display: val.Display, CodeSuffix: suffix}
+			vals[index] = complexCandidate{Value: val.Value, Display: val.Display, CodeSuffix: suffix, Style: val.Style}
 		} else {
-			vals[index] = complexCandidate{Value: val.Value, Display: fmt.Sprintf(`%v (%v)`, val.Display, val.Description), CodeSuffix: suffix}
+			vals[index] = complexCandidate{Value: val.Value, Display: fmt.Sprintf(`%v (%v)`, val.Display, val.Description), CodeSuffix: suffix, Style: val.Style}
 		}
 	}
 	m, _ := json.Marshal(vals)
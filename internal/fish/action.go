@@ -2,8 +2,10 @@ package fish
 
 import (
 	"fmt"
-	"github.com/rsteube/carapace/internal/common"
+	"sort"
 	"strings"
+
+	"github.com/rsteube/carapace/internal/common"
 )
 
 var sanitizer = strings.NewReplacer(
@@ -14,6 +16,8 @@ var sanitizer = strings.NewReplacer(
 
 // ActionRawValues formats values for fish
 func ActionRawValues(currentWord string, nospace bool, values common.RawValues) string {
+	sort.Stable(common.ByTag(values)) // fish has no result kinds so values are only grouped by tag (order is kept with `complete -k`)
+
 	vals := make([]string, len(values))
 	for index, val := range values {
 		vals[index] = fmt.Sprintf("%v\t%v", sanitizer.Replace(val.Value), sanitizer.Replace(val.TrimmedDescription()))
@@ -26,6 +26,6 @@ function _%v_callback
 end
 
 complete -c %v -f
-complete -c '%v' -f -a '(_%v_callback)' -r
+complete -c '%v' -f -k -a '(_%v_callback)' -r
 `, cmd.Name(), cmd.Name(), cmd.Name(), uid.Executable(), cmd.Name(), cmd.Name(), cmd.Name())
 }
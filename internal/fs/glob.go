@@ -0,0 +1,130 @@
+// Package fs provides helpers for file completion
+package fs
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ExpandBraces expands brace expressions of given pattern
+//   ExpandBraces("*.tar.{gz,xz}") // ["*.tar.gz", "*.tar.xz"]
+func ExpandBraces(pattern string) []string {
+	start := strings.Index(pattern, "{")
+	if start == -1 {
+		return []string{pattern}
+	}
+
+	depth := 0
+	alternatives := make([]string, 0)
+	last := start + 1
+	for index := start; index < len(pattern); index++ {
+		switch pattern[index] {
+		case '{':
+			depth++
+		case ',':
+			if depth == 1 {
+				alternatives = append(alternatives, pattern[last:index])
+				last = index + 1
+			}
+		case '}':
+			depth--
+			if depth == 0 {
+				alternatives = append(alternatives, pattern[last:index])
+				expanded := make([]string, 0)
+				for _, alternative := range alternatives {
+					expanded = append(expanded, ExpandBraces(pattern[:start]+alternative+pattern[index+1:])...)
+				}
+				return expanded
+			}
+		}
+	}
+	return []string{pattern} // unbalanced braces are taken literally
+}
+
+// MatchAny checks if name matches any of given glob patterns (brace expressions are supported)
+//   MatchAny("archive.tar.gz", "*.tar.{gz,xz}") // true
+func MatchAny(name string, patterns ...string) bool {
+	for _, pattern := range patterns {
+		for _, expanded := range ExpandBraces(pattern) {
+			if matched, _ := filepath.Match(expanded, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// globRegexp converts a gitignore style glob pattern to a regular expression (fails on invalid character classes)
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var builder strings.Builder
+	builder.WriteString("^")
+	for index := 0; index < len(pattern); index++ {
+		switch c := pattern[index]; c {
+		case '*':
+			if strings.HasPrefix(pattern[index:], "**/") {
+				builder.WriteString("(.*/)?")
+				index += 2
+			} else if strings.HasPrefix(pattern[index:], "**") {
+				builder.WriteString(".*")
+				index++
+			} else {
+				builder.WriteString("[^/]*")
+			}
+		case '?':
+			builder.WriteString("[^/]")
+		case '[':
+			if class, length := globClass(pattern[index:]); length > 0 {
+				builder.WriteString(class)
+				index += length - 1
+			} else {
+				builder.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		case '\\':
+			if index+1 < len(pattern) {
+				index++
+				builder.WriteString(regexp.QuoteMeta(string(pattern[index])))
+			}
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	builder.WriteString("$")
+	return regexp.Compile(builder.String())
+}
+
+// globClass converts the bracket expression at the start of pattern to a character class
+// (length is zero if it is not terminated)
+//   globClass("[!a-z]*")        // "[^a-z]", 6
+//   globClass("[[:digit:]_].go") // "[[:digit:]_]", 12
+func globClass(pattern string) (class string, length int) {
+	var builder strings.Builder
+	builder.WriteString("[")
+	index := 1
+	if index < len(pattern) && (pattern[index] == '!' || pattern[index] == '^') {
+		builder.WriteString("^")
+		index++
+	}
+	for start := index; index < len(pattern); index++ {
+		switch c := pattern[index]; {
+		case c == ']' && index > start: // a leading `]` is taken literally
+			builder.WriteString("]")
+			return builder.String(), index + 1
+		case c == '[' && strings.HasPrefix(pattern[index:], "[:"):
+			if end := strings.Index(pattern[index+2:], ":]"); end != -1 {
+				builder.WriteString(pattern[index : index+end+4]) // posix class like `[:digit:]` (also supported by regexp)
+				index += end + 3
+			} else {
+				builder.WriteString(`\[`)
+			}
+		case c == '\\' && index+1 < len(pattern):
+			index++
+			builder.WriteString(regexp.QuoteMeta(string(pattern[index])))
+		case c == '-':
+			builder.WriteString("-")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return "", 0
+}
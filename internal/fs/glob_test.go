@@ -0,0 +1,60 @@
+package fs
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExpandBraces(t *testing.T) {
+	for pattern, expected := range map[string]string{
+		"*.go":              "[*.go]",
+		"*.tar.{gz,xz}":     "[*.tar.gz *.tar.xz]",
+		"{a,b{c,d}}.txt":    "[a.txt bc.txt bd.txt]",
+		"{x,y}.{1,2}":       "[x.1 x.2 y.1 y.2]",
+		"unbalanced{a,b":    "[unbalanced{a,b]",
+		"empty{,.bak}.conf": "[empty.conf empty.bak.conf]",
+	} {
+		if actual := fmt.Sprint(ExpandBraces(pattern)); actual != expected {
+			t.Errorf("%v: expected %v but was %v", pattern, expected, actual)
+		}
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	if !MatchAny("archive.tar.xz", "*.zip", "*.tar.{gz,xz}") {
+		t.Error("archive.tar.xz should match")
+	}
+	if MatchAny("archive.tar.bz2", "*.zip", "*.tar.{gz,xz}") {
+		t.Error("archive.tar.bz2 should not match")
+	}
+	if MatchAny("anything") {
+		t.Error("no patterns should not match")
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	for pattern, expected := range map[string]map[string]bool{
+		"log[[:digit:]].txt": {"log1.txt": true, "logx.txt": false, "log:.txt": false},
+		"[!a-c]*":            {"data": true, "build": false},
+		"[]a]":               {"]": true, "a": true, "b": false},
+		"[[:alpha:]-]x":      {"ax": true, "-x": true, "1x": false},
+		"file\\[1]":          {"file[1]": true, "file1": false},
+		"unterminated[a":     {"unterminated[a": true, "unterminateda": false},
+	} {
+		regex, err := globRegexp(pattern)
+		if err != nil {
+			t.Fatalf("%v: %v", pattern, err)
+		}
+		for name, matches := range expected {
+			if actual := regex.MatchString(name); actual != matches {
+				t.Errorf("%v: expected %v for %v but was %v", pattern, matches, name, actual)
+			}
+		}
+	}
+
+	for _, pattern := range []string{"[z-a]", "[[:unknown:]]"} {
+		if _, err := globRegexp(pattern); err == nil {
+			t.Errorf("%v: expected an error", pattern)
+		}
+	}
+}
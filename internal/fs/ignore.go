@@ -0,0 +1,121 @@
+package fs
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type rule struct {
+	base     string
+	regex    *regexp.Regexp
+	anchored bool
+	negate   bool
+	dirOnly  bool
+}
+
+// Ignore contains the rules of `.gitignore` and `.ignore` files
+type Ignore struct {
+	rules []rule
+}
+
+// LoadIgnore loads the ignore files of given directory and its parents up to the repository root (containing `.git`)
+func LoadIgnore(dir string) Ignore {
+	dirs := []string{dir}
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			break // repository root
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			dirs = dirs[:1] // not within a repository so only the directory itself is considered
+			break
+		}
+		current = parent
+		dirs = append(dirs, current)
+	}
+
+	ignore := Ignore{}
+	for index := len(dirs) - 1; index >= 0; index-- { // parents first so that nested rules take precedence
+		for _, name := range []string{".gitignore", ".ignore"} {
+			ignore.rules = append(ignore.rules, parseIgnoreFile(dirs[index], name)...)
+		}
+	}
+	return ignore
+}
+
+func parseIgnoreFile(dir, name string) []rule {
+	file, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	rules := make([]rule, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if r, ok := parseIgnoreRule(dir, scanner.Text()); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func parseIgnoreRule(base, line string) (rule, bool) {
+	line = strings.TrimRight(line, " \r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:] // escaped `#` or `!`
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+	regex, err := globRegexp(line)
+	if err != nil {
+		return rule{}, false // skipped like git does (e.g. invalid range `[z-a]`)
+	}
+	r.regex = regex
+	return r, true
+}
+
+// Match checks if given path is ignored
+func (i Ignore) Match(path string, isDir bool) bool {
+	ignored := false
+	for _, r := range i.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(r.base, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		if r.anchored {
+			if !r.regex.MatchString(rel) {
+				continue
+			}
+		} else if !r.regex.MatchString(filepath.Base(rel)) {
+			continue
+		}
+		ignored = !r.negate
+	}
+	return ignored
+}
@@ -0,0 +1,46 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnore(t *testing.T) {
+	root, err := ioutil.TempDir("", "carapace-ignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(filepath.Join(root, ".git"), 0755)
+	os.MkdirAll(filepath.Join(root, "sub", "build"), 0755)
+	ioutil.WriteFile(filepath.Join(root, ".gitignore"), []byte("# comment\nnode_modules/\n*.log\n!keep.log\n/dist\ndocs/**/*.html\n[z-a]\nreport[[:digit:]].csv\n"), 0644)
+	ioutil.WriteFile(filepath.Join(root, "sub", ".ignore"), []byte("build/\n"), 0644)
+
+	ignore := LoadIgnore(filepath.Join(root, "sub"))
+	for path, expected := range map[string]bool{
+		"sub/node_modules":      true,
+		"sub/debug.log":         true,
+		"sub/keep.log":          false,
+		"sub/build":             true,
+		"sub/main.go":           false,
+		"sub/dist":              false, // anchored to root
+		"dist":                  true,
+		"docs/api/index.html":   true,
+		"docs/index.html":       true,
+		"docs/api/index.html.1": false,
+		"sub/report1.csv":       true,
+		"sub/reportx.csv":       false,
+	} {
+		isDir := filepath.Ext(path) == ""
+		if actual := ignore.Match(filepath.Join(root, path), isDir); actual != expected {
+			t.Errorf("%v: expected %v but was %v", path, expected, actual)
+		}
+	}
+
+	if ignore.Match(filepath.Join(root, "sub", "node_modules"), false) {
+		t.Error("directory rules should not match files")
+	}
+}
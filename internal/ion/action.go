@@ -3,7 +3,6 @@ package ion
 import (
 	"encoding/json"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
@@ -30,8 +29,7 @@ type suggestion struct {
 
 // ActionRawValues formats values for ion
 func ActionRawValues(currentWord string, nospace bool, values common.RawValues) string {
-	filtered := values.FilterPrefix(currentWord)
-	sort.Sort(common.ByDisplay(filtered))
+	filtered := values // already filtered
 
 	vals := make([]suggestion, len(filtered))
 	for index, val := range sanitize(filtered) {
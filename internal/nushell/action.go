@@ -26,12 +26,13 @@ func sanitize(values []common.RawValue) []common.RawValue {
 type suggestion struct {
 	Display     string `json:"display"`
 	Replacement string `json:"replacement"`
+	Style       string `json:"style,omitempty"`
 }
 
 // ActionRawValues formats values for nushell
 func ActionRawValues(currentWord string, nospace bool, values common.RawValues) string {
-	filtered := values.FilterPrefix(currentWord)
-	sort.Sort(common.ByDisplay(filtered))
+	filtered := values                  // already filtered
+	sort.Stable(common.ByTag(filtered)) // group values by tag (order within a group is kept)
 
 	vals := make([]suggestion, len(filtered))
 	for index, val := range sanitize(filtered) {
@@ -44,9 +45,9 @@ func ActionRawValues(currentWord string, nospace bool, values common.RawValues)
 		}
 
 		if val.Description == "" {
-			vals[index] = suggestion{Display: val.Display, Replacement: val.Value}
+			vals[index] = suggestion{Display: val.Display, Replacement: val.Value, Style: val.Style}
 		} else {
-			vals[index] = suggestion{Display: fmt.Sprintf(`%v (%v)`, val.Display, val.TrimmedDescription()), Replacement: val.Value}
+			vals[index] = suggestion{Display: fmt.Sprintf(`%v (%v)`, val.Display, val.TrimmedDescription()), Replacement: val.Value, Style: val.Style}
 		}
 	}
 	m, _ := json.Marshal(vals)
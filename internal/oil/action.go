@@ -16,14 +16,7 @@ const nospaceIndicator = "\001"
 
 // ActionRawValues formats values for oil
 func ActionRawValues(currentWord string, nospace bool, values common.RawValues) string {
-	filtered := make([]common.RawValue, 0)
-
-	for _, r := range values {
-		// TODO should rather access callbackvalue (circular dependency) - seems to work though so good enough for now
-		if strings.HasPrefix(r.Value, currentWord) {
-			filtered = append(filtered, r)
-		}
-	}
+	filtered := values // already filtered
 
 	vals := make([]string, len(filtered))
 	for index, val := range filtered {
@@ -3,10 +3,10 @@ package powershell
 import (
 	"encoding/json"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/pkg/style"
 )
 
 var sanitizer = strings.NewReplacer( // TODO
@@ -18,9 +18,26 @@ var sanitizer = strings.NewReplacer( // TODO
 type completionResult struct {
 	CompletionText string
 	ListItemText   string
+	ResultType     string
 	ToolTip        string
 }
 
+// resultType maps the tag of a value to a `System.Management.Automation.CompletionResultType`
+func resultType(tag string) string {
+	switch tag {
+	case common.TagCommands:
+		return "Command"
+	case common.TagDirectories:
+		return "ProviderContainer"
+	case common.TagFiles:
+		return "ProviderItem"
+	case common.TagFlags:
+		return "ParameterName"
+	default:
+		return "ParameterValue"
+	}
+}
+
 // CompletionResult doesn't like empty parameters, so just replace with space if needed
 func ensureNotEmpty(s string) string {
 	if s == "" {
@@ -29,18 +46,26 @@ func ensureNotEmpty(s string) string {
 	return s
 }
 
+// styled wraps given text in ANSI escape sequences (rendered by PSReadLine)
+func styled(s string, _style string) string {
+	if sgr := style.SGR(_style); sgr != "" {
+		return fmt.Sprintf("\x1b[%vm%v\x1b[0m", sgr, s)
+	}
+	return s
+}
+
 // ActionRawValues formats values for powershell
 func ActionRawValues(currentWord string, nospace bool, values common.RawValues) string {
-	filtered := common.ByValue(values).Filter(currentWord)
-	sort.Sort(common.ByDisplay(filtered))
+	filtered := values // already filtered
 
 	vals := make([]completionResult, 0, len(filtered))
 	for _, val := range filtered {
 		if val.Value != "" { // must not be empty - any empty `''` parameter in CompletionResult causes an error
 			val.Value = sanitizer.Replace(val.Value)
 
-			if strings.ContainsAny(val.Value, ` {}()[]*$?\"|<>&(),;#`+"`") {
-				val.Value = fmt.Sprintf("'%v'", val.Value)
+			prefix := common.ExpansionPrefix(val.Value, currentWord) // kept unquoted so that `$HOME/` and `~/` are expanded
+			if remainder := val.Value[len(prefix):]; strings.ContainsAny(remainder, ` {}()[]*$?\"|<>&(),;#`+"`") {
+				val.Value = fmt.Sprintf("%v'%v'", prefix, remainder)
 			}
 
 			if !nospace {
@@ -49,7 +74,8 @@ func ActionRawValues(currentWord string, nospace bool, values common.RawValues)
 
 			vals = append(vals, completionResult{
 				CompletionText: val.Value,
-				ListItemText:   ensureNotEmpty(sanitizer.Replace(val.Display)),
+				ListItemText:   styled(ensureNotEmpty(sanitizer.Replace(val.Display)), val.Style),
+				ResultType:     resultType(val.Tag),
 				ToolTip:        ensureNotEmpty(sanitizer.Replace(val.TrimmedDescription())),
 			})
 		}
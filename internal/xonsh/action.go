@@ -18,27 +18,23 @@ type richCompletion struct {
 	Value       string
 	Display     string
 	Description string
+	Style       string
 }
 
 // ActionRawValues formats values for xonsh
 func ActionRawValues(currentWord string, nospace bool, values common.RawValues) string {
-	filtered := make([]common.RawValue, 0)
-
-	for _, r := range values {
-		if strings.HasPrefix(r.Value, currentWord) {
-			filtered = append(filtered, r)
-		}
-	}
+	filtered := values // already filtered
 
 	vals := make([]richCompletion, len(filtered))
 	for index, val := range filtered {
 		val.Value = sanitizer.Replace(val.Value)
 
-		if strings.ContainsAny(val.Value, ` ()[]{}*$?\"|<>&;#`+"`") {
-			if strings.Contains(val.Value, `\`) {
-				val.Value = fmt.Sprintf("r'%v'", val.Value) // backslash needs raw string
+		prefix := common.ExpansionPrefix(val.Value, currentWord) // kept unquoted so that `$HOME/` and `~/` are expanded
+		if remainder := val.Value[len(prefix):]; strings.ContainsAny(remainder, ` ()[]{}*$?\"|<>&;#`+"`") {
+			if strings.Contains(remainder, `\`) {
+				val.Value = fmt.Sprintf("%vr'%v'", prefix, remainder) // backslash needs raw string
 			} else {
-				val.Value = fmt.Sprintf("'%v'", val.Value)
+				val.Value = fmt.Sprintf("%v'%v'", prefix, remainder)
 			}
 		}
 
@@ -46,7 +42,8 @@ func ActionRawValues(currentWord string, nospace bool, values common.RawValues)
 			val.Value = val.Value + " "
 		}
 
-		vals[index] = richCompletion{Value: val.Value, Display: val.Display, Description: val.TrimmedDescription()}
+		// style class based on the tag so values can be themed (e.g. `class:carapace.flags`)
+		vals[index] = richCompletion{Value: val.Value, Display: val.Display, Description: val.TrimmedDescription(), Style: "class:carapace." + val.TagOrDefault()}
 	}
 	m, _ := json.Marshal(vals)
 	return string(m)
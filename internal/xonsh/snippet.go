@@ -27,11 +27,11 @@ def _%v_completer(context):
 
         output, _ = Popen(['%v', '_carapace', 'xonsh', '_', *[a.value for a in context.args], fix_prefix(context.prefix)], stdout=PIPE, stderr=PIPE).communicate()
         try:
-            result = {RichCompletion(c["Value"], display=c["Display"], description=c["Description"], prefix_len=len(context.raw_prefix), append_closing_quote=False) for c in loads(output)}
+            result = [RichCompletion(c["Value"], display=c["Display"], description=c["Description"], style=c["Style"], prefix_len=len(context.raw_prefix), append_closing_quote=False) for c in loads(output)]
         except:
-            result = {}
+            result = []
         if len(result) == 0:
-            result = {RichCompletion(context.prefix, display=context.prefix, description='', prefix_len=len(context.raw_prefix), append_closing_quote=False)}
+            result = [RichCompletion(context.prefix, display=context.prefix, description='', prefix_len=len(context.raw_prefix), append_closing_quote=False)]
         return result
 
 
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/pkg/style"
 )
 
 var sanitizer = strings.NewReplacer(
@@ -14,20 +15,41 @@ var sanitizer = strings.NewReplacer(
 	`'`, `'\''`,
 )
 
-// ActionRawValues formats values for zsh
+var patternQuoter = strings.NewReplacer(
+	`\`, `\\`,
+	`(`, `\(`,
+	`)`, `\)`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`|`, `\|`,
+	`*`, `\*`,
+	`?`, `\?`,
+	`#`, `\#`,
+	`~`, `\~`,
+	`^`, `\^`,
+	`<`, `\<`,
+	`>`, `\>`,
+	`=`, `\=`,
+	`:`, `\:`,
+)
+
+// listColor creates a `list-colors` zstyle entry highlighting given display value
+func listColor(display string, sgr string) string {
+	return fmt.Sprintf("=(#b)(%v)([ ]##-- *|)=0=%v", patternQuoter.Replace(display), sgr)
+}
+
+// ActionRawValues formats values for zsh (first line contains the `list-colors` zstyle, remaining ones are prefixed with the tag)
 func ActionRawValues(currentWord string, nospace bool, values common.RawValues) string {
-	filtered := make([]common.RawValue, 0)
+	filtered := values // already filtered
 
 	maxLength := 0
-	for _, r := range values {
-		if strings.HasPrefix(r.Value, currentWord) {
-			filtered = append(filtered, r)
-			if length := len(r.Display); length > maxLength {
-				maxLength = length
-			}
+	for _, r := range filtered {
+		if length := len(r.Display); length > maxLength {
+			maxLength = length
 		}
 	}
 
+	listColors := make([]string, 0)
 	vals := make([]string, len(filtered))
 	for index, val := range filtered {
 		val.Value = sanitizer.Replace(val.Value)
@@ -37,11 +59,15 @@ func ActionRawValues(currentWord string, nospace bool, values common.RawValues)
 		val.Display = sanitizer.Replace(val.Display)
 		val.Description = sanitizer.Replace(val.Description)
 
+		if sgr := style.SGR(val.Style); sgr != "" {
+			listColors = append(listColors, listColor(val.Display, sgr))
+		}
+
 		if strings.TrimSpace(val.Description) == "" {
-			vals[index] = fmt.Sprintf("%v\t%v", val.Value, val.Display)
+			vals[index] = fmt.Sprintf("%v\t%v\t%v", val.TagOrDefault(), val.Value, val.Display)
 		} else {
-			vals[index] = fmt.Sprintf("%v\t%v %v-- %v", val.Value, val.Display, strings.Repeat(" ", maxLength-len(val.Display)), val.TrimmedDescription())
+			vals[index] = fmt.Sprintf("%v\t%v\t%v %v-- %v", val.TagOrDefault(), val.Value, val.Display, strings.Repeat(" ", maxLength-len(val.Display)), val.TrimmedDescription())
 		}
 	}
-	return strings.Join(vals, "\n")
+	return strings.Join(listColors, "\t") + "\n" + strings.Join(vals, "\n")
 }
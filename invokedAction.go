@@ -1,6 +1,7 @@
 package carapace
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/rsteube/carapace/internal/bash"
@@ -35,30 +36,58 @@ func (a InvokedAction) Filter(values []string) InvokedAction {
 			filtered = append(filtered, rawValue)
 		}
 	}
-	return InvokedAction{actionRawValues(filtered...).noSpace(a.nospace).skipCache(a.skipcache)}
+	return InvokedAction{actionRawValues(filtered...).noSpace(a.nospace).skipCache(a.skipcache).keepOrder(a.keeporder).match(a.matcher, a.matchdesc).withSegment(a.segment)}
 }
 
-// Merge merges InvokedActions (existing values are overwritten)
+// Merge merges InvokedActions (existing values are overwritten but keep their position)
 //   a := carapace.ActionValues("A", "B").Invoke(c)
 //   b := carapace.ActionValues("B", "C").Invoke(c)
 //   c := a.Merge(b) // ["A", "B", "C"]
 func (a InvokedAction) Merge(others ...InvokedAction) InvokedAction {
-	uniqueRawValues := make(map[string]common.RawValue)
+	positions := make(map[string]int)
+	rawValues := make([]common.RawValue, 0)
 	nospace := a.nospace
 	skipcache := a.skipcache
+	keeporder := a.keeporder
+	matcher := a.matcher
+	matchdesc := a.matchdesc
+	segment := ""
+	first := true
 	for _, other := range append([]InvokedAction{a}, others...) {
+		if len(other.rawValues) > 0 {
+			if first {
+				segment = other.segment
+				first = false
+			} else {
+				segment = commonPrefix(segment, other.segment)
+			}
+		}
 		for _, c := range other.rawValues {
-			uniqueRawValues[c.Value] = c
+			if position, ok := positions[c.Value]; ok {
+				rawValues[position] = c
+			} else {
+				positions[c.Value] = len(rawValues)
+				rawValues = append(rawValues, c)
+			}
 		}
 		nospace = nospace || other.nospace
 		skipcache = skipcache || other.skipcache
+		keeporder = keeporder || other.keeporder
+		if matcher == nil {
+			matcher = other.matcher
+		}
+		matchdesc = matchdesc || other.matchdesc
 	}
+	return InvokedAction{actionRawValues(rawValues...).noSpace(nospace).skipCache(skipcache).keepOrder(keeporder).match(matcher, matchdesc).withSegment(segment)}
+}
 
-	rawValues := make([]common.RawValue, 0, len(uniqueRawValues))
-	for _, c := range uniqueRawValues {
-		rawValues = append(rawValues, c)
+func commonPrefix(a, b string) string {
+	for index := range a {
+		if index >= len(b) || a[index] != b[index] {
+			return a[:index]
+		}
 	}
-	return InvokedAction{actionRawValues(rawValues...).noSpace(nospace).skipCache(skipcache)}
+	return a
 }
 
 // Prefix adds a prefix to values (only the ones inserted, not the display values)
@@ -68,6 +97,16 @@ func (a InvokedAction) Prefix(prefix string) InvokedAction {
 	for index, val := range a.rawValues {
 		a.rawValues[index].Value = prefix + val.Value
 	}
+	if a.segment != "" {
+		a.segment = prefix + a.segment
+	}
+	return a
+}
+
+// prefixSegment adds a prefix that was split off at a divider (skipped by the matcher)
+func (a InvokedAction) prefixSegment(prefix string) InvokedAction {
+	a = a.Prefix(prefix)
+	a.segment = prefix + a.segment
 	return a
 }
 
@@ -91,24 +130,33 @@ func (a InvokedAction) ToA() Action {
 //   b := a.ToMultiPartsA("/") // completes segments separately (first one is ["A/", "B/", "C"])
 func (a InvokedAction) ToMultiPartsA(divider string) Action {
 	return ActionMultiParts(divider, func(c Context) Action {
-		uniqueVals := make(map[string]string)
+		positions := make(map[string]int)
+		vals := make([]common.RawValue, 0)
 		for _, val := range a.rawValues {
 			if strings.HasPrefix(val.Value, strings.Join(c.Parts, divider)) {
 				if splitted := strings.Split(val.Value, divider); len(splitted) > len(c.Parts) {
+					var rawValue common.RawValue
 					if len(splitted) == len(c.Parts)+1 {
-						uniqueVals[splitted[len(c.Parts)]] = val.Description
+						part := splitted[len(c.Parts)]
+						rawValue = common.RawValue{Value: part, Display: part, Description: val.Description, Style: val.Style, Tag: val.Tag}
+					} else if len(splitted) == len(c.Parts)+2 && splitted[len(splitted)-1] == "" { // value ends with divider (e.g. directory)
+						part := splitted[len(c.Parts)] + divider
+						rawValue = common.RawValue{Value: part, Display: part, Description: val.Description, Style: val.Style, Tag: val.Tag}
+					} else {
+						part := splitted[len(c.Parts)] + divider
+						rawValue = common.RawValue{Value: part, Display: part, Tag: val.Tag}
+					}
+
+					if position, ok := positions[rawValue.Value]; ok {
+						vals[position] = rawValue
 					} else {
-						uniqueVals[splitted[len(c.Parts)]+divider] = ""
+						positions[rawValue.Value] = len(vals)
+						vals = append(vals, rawValue)
 					}
 				}
 			}
 		}
-
-		vals := make([]string, 0, len(uniqueVals)*2)
-		for val, description := range uniqueVals {
-			vals = append(vals, val, description)
-		}
-		return ActionValuesDescribed(vals...).noSpace(true)
+		return actionRawValues(vals...).noSpace(true).keepOrder(a.keeporder).match(a.matcher, a.matchdesc)
 	})
 }
 
@@ -126,7 +174,38 @@ func (a InvokedAction) value(shell string, callbackValue string) string { // TOD
 		"zsh":        zsh.ActionRawValues,
 	}
 	if f, ok := shellFuncs[shell]; ok {
-		return f(callbackValue, a.nospace, a.rawValues)
+		return f(callbackValue, a.nospace, a.filter(callbackValue))
 	}
 	return ""
 }
+
+// filter returns the (ordered) values matching the word currently being completed
+func (a InvokedAction) filter(word string) common.RawValues {
+	matcher := a.matcher
+	if matcher == nil {
+		matcher = opts.Matcher
+	}
+	matchdesc := a.matchdesc || opts.MatchDescriptions
+
+	filtered := make(common.RawValues, 0)
+	for _, rawValue := range a.orderedRawValues() {
+		value, current := rawValue.Value, word
+		if strings.HasPrefix(value, a.segment) && strings.HasPrefix(current, a.segment) {
+			value, current = value[len(a.segment):], current[len(a.segment):] // only match the part currently completed
+		}
+		if matcher.Match(value, rawValue.Description, current, matchdesc) {
+			filtered = append(filtered, rawValue)
+		}
+	}
+	return filtered
+}
+
+// orderedRawValues returns the values sorted by display unless the order is kept
+func (a InvokedAction) orderedRawValues() common.RawValues {
+	rawValues := make(common.RawValues, len(a.rawValues))
+	copy(rawValues, a.rawValues)
+	if !a.keeporder {
+		sort.Stable(common.ByDisplay(rawValues))
+	}
+	return rawValues
+}
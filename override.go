@@ -3,6 +3,11 @@ package carapace
 import (
 	"os"
 	"strings"
+	"time"
+
+	"github.com/rsteube/carapace/internal/cache"
+	pkgcache "github.com/rsteube/carapace/pkg/cache"
+	"github.com/rsteube/carapace/pkg/match"
 )
 
 var opts Opts
@@ -20,6 +25,24 @@ type Opts struct {
 	OptArgDelimiter string
 	// BridgeCompletion registers carapace completions to cobra's default completion
 	BridgeCompletion bool
+	// Matcher sets the strategy to match values against the word currently being completed
+	//   match.Prefix          // eu<TAB> -> eu-prod-cluster (default)
+	//   match.CaseInsensitive // EU<TAB> -> eu-prod-cluster
+	//   match.Substring       // prod<TAB> -> eu-prod-cluster
+	//   match.Fuzzy           // epc<TAB> -> eu-prod-cluster
+	Matcher match.Matcher
+	// MatchDescriptions additionally matches the descriptions of values
+	MatchDescriptions bool
+	// Cache sets the storage backend for Action.Cache (defaults to a file per entry in the user cache directory)
+	//   cache.NewMemoryCache()                     // e.g. for tests and long-lived hosts
+	//   cache.NewSingleFileCache("/tmp/cache.json") // all entries within a single file
+	Cache pkgcache.Cache
+	// CacheMaxSize sets the maximum total size in bytes of cached entries (defaults to 100MiB)
+	// Least recently used entries are evicted when exceeded.
+	CacheMaxSize int64
+	// Timeout sets the default budget for a completion invocation (disabled when zero)
+	//   2 * time.Second // returns a message when exceeded (e.g. unresponsive remote)
+	Timeout time.Duration
 }
 
 func init() {
@@ -58,4 +81,33 @@ func Override(o Opts) {
 	}
 
 	opts.BridgeCompletion = o.BridgeCompletion
+	opts.Matcher = o.Matcher
+	opts.MatchDescriptions = o.MatchDescriptions
+	opts.Timeout = o.Timeout
+	opts.Cache = o.Cache
+	opts.CacheMaxSize = o.CacheMaxSize
+}
+
+// withTimeout applies the default budget set by Opts.Timeout
+func withTimeout(a Action) Action {
+	if opts.Timeout > 0 {
+		return a.Timeout(opts.Timeout)
+	}
+	return a
+}
+
+// cacheBackend returns the backend set by Opts.Cache or the default one
+func cacheBackend() (pkgcache.Cache, error) {
+	if opts.Cache != nil {
+		return opts.Cache, nil
+	}
+	return cache.Default()
+}
+
+// cacheMaxSize returns the size set by Opts.CacheMaxSize or the default one
+func cacheMaxSize() int64 {
+	if opts.CacheMaxSize > 0 {
+		return opts.CacheMaxSize
+	}
+	return cache.DefaultMaxSize
 }
@@ -0,0 +1,12 @@
+// +build !linux,!openbsd,!dragonfly,!solaris,!darwin,!freebsd,!netbsd,!windows
+
+package cache
+
+import (
+	"os"
+	"time"
+)
+
+func accessTime(info os.FileInfo) time.Time {
+	return info.ModTime() // access time not supported
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotExist is returned when a cache entry does not exist
+var ErrNotExist = errors.New("cache entry does not exist")
+
+// Entry is a cached item
+type Entry struct {
+	ID         string
+	Content    []byte // not set by Tracker.Entries
+	Size       int64
+	ModTime    time.Time // last write
+	AccessTime time.Time // last use (see Tracker)
+}
+
+// Cache is a storage backend for cached values (ids are slash separated paths like `{callerUid}/{keysUid}`)
+//   carapace.Override(carapace.Opts{
+//       Cache: cache.NewMemoryCache(),
+//   })
+type Cache interface {
+	// Load returns the entry for given id or ErrNotExist
+	Load(id string) (Entry, error)
+	// Write persists content for given id
+	Write(id string, content []byte) error
+	// Delete removes the entry for given id (does not fail if it does not exist)
+	Delete(id string) error
+	// List returns the ids of all entries starting with given prefix
+	List(prefix string) ([]string, error)
+}
+
+// Tracker is optionally implemented by a Cache to evict the least recently used entries without loading them
+// (otherwise entries are loaded for their size and the modification time is used instead of the access time)
+type Tracker interface {
+	// Entries returns all entries (without content) whose id starts with given prefix
+	Entries(prefix string) ([]Entry, error)
+	// Touch marks the entry for given id as recently used (called at most once per cleanup interval)
+	Touch(id string) error
+}
+
+// Locker is optionally implemented by a Cache to prevent concurrent refreshes of the same entry
+type Locker interface {
+	// TryLock acquires the lock for given id without blocking (ok is false if it is already held)
+	TryLock(id string) (unlock func(), ok bool, err error)
+}
+
+// entryIDs returns the ids of given entries
+func entryIDs(entries []Entry, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids, nil
+}
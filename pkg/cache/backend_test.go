@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func ids(entries []Entry) []string {
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func testBackend(t *testing.T, backend Cache) {
+	if _, err := backend.Load("caller/missing"); err != ErrNotExist {
+		t.Errorf("expected ErrNotExist but was %v", err)
+	}
+
+	for _, id := range []string{"caller1/a", "caller1/b", "caller2/a"} {
+		if err := backend.Write(id, []byte("content of "+id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entry, err := backend.Load("caller1/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(entry.Content) != "content of caller1/b" || entry.ID != "caller1/b" || entry.ModTime.IsZero() {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	if ids, err := backend.List("caller1/"); err != nil || fmt.Sprint(ids) != "[caller1/a caller1/b]" {
+		t.Errorf("unexpected ids: %v %v", ids, err)
+	}
+
+	if err := backend.Delete("caller1/a"); err != nil {
+		t.Error(err)
+	}
+	if err := backend.Delete("caller1/a"); err != nil {
+		t.Errorf("deleting a missing entry should not fail: %v", err)
+	}
+	if ids, err := backend.List(""); err != nil || fmt.Sprint(ids) != "[caller1/b caller2/a]" {
+		t.Errorf("unexpected ids: %v %v", ids, err)
+	}
+}
+
+func testTracker(t *testing.T, backend Cache) {
+	tracker, ok := backend.(Tracker)
+	if !ok {
+		t.Fatalf("expected %T to implement Tracker", backend)
+	}
+
+	for _, id := range []string{"caller1/a", "caller1/b", "caller2/a"} {
+		if err := backend.Write(id, []byte("content of "+id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := tracker.Entries("caller1/")
+	if err != nil || fmt.Sprint(ids(entries)) != "[caller1/a caller1/b]" {
+		t.Errorf("unexpected ids: %v %v", ids(entries), err)
+	}
+	if entries[0].Content != nil || entries[0].Size != int64(len("content of caller1/a")) {
+		t.Errorf("unexpected listed entry: %+v", entries[0])
+	}
+
+	accessed := time.Now().Add(time.Second)
+	if err := tracker.Touch("caller1/a"); err != nil {
+		t.Error(err)
+	}
+	if err := tracker.Touch("caller/missing"); err != ErrNotExist {
+		t.Errorf("expected ErrNotExist but was %v", err)
+	}
+	if entry, err := backend.Load("caller1/a"); err != nil || entry.AccessTime.After(accessed) || entry.AccessTime.Before(entry.ModTime.Add(-time.Second)) {
+		t.Errorf("unexpected access time: %+v %v", entry, err)
+	}
+}
+
+func testLocker(t *testing.T, backend Cache) {
+	locker, ok := backend.(Locker)
+	if !ok {
+		t.Fatalf("expected %T to implement Locker", backend)
+	}
+
+	unlock, ok, err := locker.TryLock("caller/a")
+	if err != nil || !ok {
+		t.Fatalf("expected lock to be acquired: %v %v", ok, err)
+	}
+	if _, ok, err := locker.TryLock("caller/a"); err != nil || ok {
+		t.Errorf("expected lock to be held: %v %v", ok, err)
+	}
+	if unlockB, ok, err := locker.TryLock("caller/b"); err != nil || !ok {
+		t.Errorf("expected lock of other id to be acquired: %v %v", ok, err)
+	} else {
+		unlockB()
+	}
+	unlock()
+
+	if unlock, ok, err := locker.TryLock("caller/a"); err != nil || !ok {
+		t.Errorf("expected lock to be released: %v %v", ok, err)
+	} else {
+		unlock()
+	}
+	if ids, err := backend.List(""); err != nil || len(ids) != 0 {
+		t.Errorf("expected locks not to be listed: %v %v", ids, err)
+	}
+
+	unlock, ok, err = locker.TryLock("caller/a")
+	if err != nil || !ok {
+		t.Fatalf("expected lock to be acquired: %v %v", ok, err)
+	}
+	if err := backend.Delete("caller/a"); err != nil {
+		t.Error(err)
+	}
+	if _, ok, err := locker.TryLock("caller/a"); err != nil || ok {
+		t.Errorf("expected lock to be held after delete: %v %v", ok, err)
+	}
+	unlock()
+	if err := backend.Delete("caller/a"); err != nil {
+		t.Error(err)
+	}
+	if unlock, ok, err := locker.TryLock("caller/a"); err != nil || !ok {
+		t.Errorf("expected lock to be acquired after delete: %v %v", ok, err)
+	} else {
+		unlock()
+	}
+}
+
+func TestFileCacheAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "carapace-atomic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backend := NewFileCache(dir)
+	small, large := []byte("small"), make([]byte, 1024*1024)
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 50; i++ {
+			if i%2 == 0 {
+				backend.Write("caller/a", large)
+			} else {
+				backend.Write("caller/a", small)
+			}
+		}
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			if ids, _ := backend.List(""); len(ids) != 1 {
+				t.Errorf("expected temporary files to be removed: %v", ids)
+			}
+			return
+		default:
+			if entry, err := backend.Load("caller/a"); err == nil && len(entry.Content) != len(small) && len(entry.Content) != len(large) {
+				t.Fatalf("read partial content: %v bytes", len(entry.Content))
+			}
+		}
+	}
+}
+
+func TestBackends(t *testing.T) {
+	dir, err := ioutil.TempDir("", "carapace-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("file", func(t *testing.T) { testBackend(t, NewFileCache(filepath.Join(dir, "files"))) })
+	t.Run("singlefile", func(t *testing.T) { testBackend(t, NewSingleFileCache(filepath.Join(dir, "cache.json"))) })
+	t.Run("memory", func(t *testing.T) { testBackend(t, NewMemoryCache()) })
+
+	t.Run("file tracker", func(t *testing.T) { testTracker(t, NewFileCache(filepath.Join(dir, "tracked"))) })
+	t.Run("singlefile tracker", func(t *testing.T) { testTracker(t, NewSingleFileCache(filepath.Join(dir, "tracked.json"))) })
+	t.Run("memory tracker", func(t *testing.T) { testTracker(t, NewMemoryCache()) })
+
+	t.Run("file locker", func(t *testing.T) { testLocker(t, NewFileCache(filepath.Join(dir, "locks"))) })
+	t.Run("singlefile locker", func(t *testing.T) { testLocker(t, NewSingleFileCache(filepath.Join(dir, "locks.json"))) })
+	t.Run("memory locker", func(t *testing.T) { testLocker(t, NewMemoryCache()) })
+}
@@ -6,8 +6,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+
+	exec "golang.org/x/sys/execabs"
 )
 
 // Key provides a cache key
@@ -44,3 +47,120 @@ func FileStats(file string) Key {
 		return
 	}
 }
+
+// Env creates a CacheKey for given environment variables (distinguishes unset from empty)
+// Variables are looked up in env (e.g. Context.Env) or the process environment if it is nil.
+//   cache.Env(c.Env, "AWS_PROFILE", "AWS_REGION")
+func Env(env map[string]string, names ...string) Key {
+	return func() (string, error) {
+		pairs := make([]string, 0, len(names))
+		for _, name := range names {
+			if value, ok := lookupEnv(env, name); ok {
+				pairs = append(pairs, name+"="+value)
+			} else {
+				pairs = append(pairs, name)
+			}
+		}
+		return String(pairs...)()
+	}
+}
+
+func lookupEnv(env map[string]string, name string) (string, bool) {
+	if env == nil {
+		return os.LookupEnv(name)
+	}
+	value, ok := env[name]
+	return value, ok
+}
+
+// WorkingDir creates a CacheKey for given working directory (e.g. Context.Dir) or the current one if empty
+//   cache.WorkingDir(c.Dir)
+func WorkingDir(wd string) Key {
+	return func() (string, error) {
+		return abs(wd, ".")
+	}
+}
+
+// abs resolves given path relative to the working directory (the current one if empty)
+func abs(wd, path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+	if wd == "" {
+		return filepath.Abs(path)
+	}
+	return filepath.Join(wd, path), nil
+}
+
+// DirStats creates a CacheKey for path, size and modification time of all files and directories within given directory (recursive)
+// Relative directories are resolved against given working directory (e.g. Context.Dir).
+//   cache.DirStats(c.Dir, "node_modules")
+func DirStats(wd, dir string) Key {
+	return func() (checksum string, err error) {
+		var root string
+		if root, err = abs(wd, dir); err != nil {
+			return
+		}
+		hash := sha1.New()
+		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(hash, "%v\n%v\n%v\n", path, info.Size(), info.ModTime().UnixNano())
+			return err
+		})
+		if err == nil {
+			checksum = fmt.Sprintf("%x", hash.Sum(nil))
+		}
+		return
+	}
+}
+
+// GlobStats creates a CacheKey for path, size and modification time of files matching given pattern (see filepath.Glob)
+// Relative patterns are resolved against given working directory (e.g. Context.Dir).
+//   cache.GlobStats(c.Dir, "*.go")
+func GlobStats(wd, pattern string) Key {
+	return func() (checksum string, err error) {
+		var absPattern string
+		if absPattern, err = abs(wd, pattern); err != nil {
+			return
+		}
+		var matches []string
+		if matches, err = filepath.Glob(absPattern); err != nil {
+			return
+		}
+		sort.Strings(matches)
+		hash := sha1.New()
+		fmt.Fprintln(hash, absPattern) // distinguish patterns without matches
+		for _, match := range matches {
+			var info os.FileInfo
+			if info, err = os.Stat(match); err != nil {
+				return
+			}
+			fmt.Fprintf(hash, "%v\n%v\n%v\n", match, info.Size(), info.ModTime().UnixNano())
+		}
+		return fmt.Sprintf("%x", hash.Sum(nil)), nil
+	}
+}
+
+// Command creates a CacheKey for the output of given command (should be cheap to execute)
+// The command is run within given working directory and environment (e.g. Context.Dir and Context.Env) which default to those of the process.
+//   cache.Command(c.Dir, c.Env, "git", "rev-parse", "HEAD")
+func Command(wd string, env map[string]string, name string, arg ...string) Key {
+	return func() (checksum string, err error) {
+		cmd := exec.Command(name, arg...)
+		cmd.Dir = wd
+		if env != nil {
+			cmd.Env = make([]string, 0, len(env))
+			for key, value := range env {
+				cmd.Env = append(cmd.Env, key+"="+value)
+			}
+		}
+
+		var output []byte
+		if output, err = cmd.Output(); err == nil {
+			checksum = fmt.Sprintf("%x", sha1.Sum(output))
+		}
+		return
+	}
+}
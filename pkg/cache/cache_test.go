@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func resolve(t *testing.T, key Key) string {
+	s, err := key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestEnv(t *testing.T) {
+	unset := resolve(t, Env(map[string]string{}, "CARAPACE_TEST_KEY"))
+	empty := resolve(t, Env(map[string]string{"CARAPACE_TEST_KEY": ""}, "CARAPACE_TEST_KEY"))
+	value := resolve(t, Env(map[string]string{"CARAPACE_TEST_KEY": "value"}, "CARAPACE_TEST_KEY"))
+
+	if unset == empty || empty == value || value != "CARAPACE_TEST_KEY=value" {
+		t.Errorf("unexpected keys: %#v %#v %#v", unset, empty, value)
+	}
+
+	defer os.Unsetenv("CARAPACE_TEST_KEY")
+	os.Setenv("CARAPACE_TEST_KEY", "process")
+	if process := resolve(t, Env(nil, "CARAPACE_TEST_KEY")); process != "CARAPACE_TEST_KEY=process" {
+		t.Errorf("expected process environment to be used: %#v", process)
+	}
+}
+
+func TestWorkingDir(t *testing.T) {
+	wd, _ := os.Getwd()
+	if current := resolve(t, WorkingDir("")); current != wd {
+		t.Errorf("expected current working directory: %#v", current)
+	}
+	if dir := resolve(t, WorkingDir("/tmp")); dir != "/tmp" {
+		t.Errorf("unexpected working directory: %#v", dir)
+	}
+}
+
+func TestDirStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "carapace-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "sub"), 0700)
+	ioutil.WriteFile(filepath.Join(dir, "sub", "a.go"), []byte("a"), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0600)
+
+	tree := resolve(t, DirStats("", dir))
+	glob := resolve(t, GlobStats(dir, filepath.Join("*", "*.go")))
+	if other := resolve(t, GlobStats(dir, "*.go")); other == glob {
+		t.Error("expected patterns to differ")
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte("changed"), 0600)
+	if resolve(t, DirStats("", dir)) == tree {
+		t.Error("expected tree key to change")
+	}
+	if resolve(t, GlobStats(dir, filepath.Join("*", "*.go"))) != glob {
+		t.Error("expected glob key to be unchanged")
+	}
+
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(dir, "sub", "a.go"), future, future)
+	if resolve(t, GlobStats(dir, filepath.Join("*", "*.go"))) == glob {
+		t.Error("expected glob key to change")
+	}
+
+	if _, err := DirStats(dir, "missing")(); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if _, err := Command("", nil, "go", "version")(); err != nil {
+		t.Error(err)
+	}
+	if _, err := Command("", nil, "go", "unknown-subcommand")(); err == nil {
+		t.Error("expected error for failing command")
+	}
+
+	if resolve(t, Command("/", nil, "pwd")) == resolve(t, Command(os.TempDir(), nil, "pwd")) {
+		t.Error("expected command to run in given directory")
+	}
+	if resolve(t, Command("", map[string]string{"KEY": "a"}, "env")) == resolve(t, Command("", map[string]string{"KEY": "b"}, "env")) {
+		t.Error("expected command to run with given environment")
+	}
+}
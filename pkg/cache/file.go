@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileCache stores each entry in a separate file within Dir (default backend)
+// Entries are written atomically and refreshes are guarded by advisory `.lock` files.
+type FileCache struct {
+	Dir string
+}
+
+const (
+	tmpSuffix  = ".tmp"
+	lockSuffix = ".lock"
+)
+
+// NewFileCache creates a FileCache using given directory
+func NewFileCache(dir string) *FileCache {
+	return &FileCache{Dir: dir}
+}
+
+func (f *FileCache) path(id string) string {
+	return filepath.Join(f.Dir, filepath.FromSlash(id))
+}
+
+func fileEntry(id string, info os.FileInfo) Entry {
+	return Entry{ID: id, Size: info.Size(), ModTime: info.ModTime(), AccessTime: accessTime(info)}
+}
+
+// Load returns the entry for given id or ErrNotExist
+func (f *FileCache) Load(id string) (Entry, error) {
+	path := f.path(id)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Entry{}, ErrNotExist
+		}
+		return Entry{}, err
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry := fileEntry(id, info)
+	entry.Content = content
+	return entry, nil
+}
+
+// Write persists content for given id
+func (f *FileCache) Write(id string, content []byte) error {
+	path := f.path(id)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return writeFile(path, content)
+}
+
+// writeFile writes to a temporary file which is then renamed so that readers never see partial content
+func writeFile(path string, content []byte) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*"+tmpSuffix)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// Delete removes the entry for given id (the lock file is kept while held by another process)
+func (f *FileCache) Delete(id string) error {
+	path := f.path(id)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	removeLock(path + lockSuffix)
+	return nil
+}
+
+// List returns the ids of all entries starting with given prefix
+func (f *FileCache) List(prefix string) ([]string, error) {
+	return entryIDs(f.Entries(prefix))
+}
+
+// Entries returns all entries (without content) whose id starts with given prefix
+func (f *FileCache) Entries(prefix string) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	err := filepath.Walk(f.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasSuffix(path, tmpSuffix) || strings.HasSuffix(path, lockSuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(f.Dir, path)
+		if err != nil {
+			return err
+		}
+		if id := filepath.ToSlash(rel); strings.HasPrefix(id, prefix) {
+			entries = append(entries, fileEntry(id, info))
+		}
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, err
+}
+
+// Touch marks the entry for given id as recently used (sets the access time of the file)
+func (f *FileCache) Touch(id string) error {
+	path := f.path(id)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotExist
+		}
+		return err
+	}
+	return os.Chtimes(path, time.Now(), info.ModTime())
+}
+
+// TryLock acquires an advisory lock for given id without blocking
+func (f *FileCache) TryLock(id string) (func(), bool, error) {
+	path := f.path(id) + lockSuffix
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, false, err
+	}
+	return tryLock(path)
+}
+
+// tryLock acquires an advisory lock on given file without blocking
+func tryLock(path string) (func(), bool, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok, err := lockFile(file, false); err != nil || !ok {
+		file.Close()
+		return nil, false, err
+	}
+	unlock := func() {
+		unlockFile(file)
+		file.Close()
+	}
+
+	// the file might have been removed by removeLock after it was opened (the lock would not be shared with the next one created)
+	locked, err := file.Stat()
+	if err != nil {
+		unlock()
+		return nil, false, err
+	}
+	if current, err := os.Stat(path); err != nil || !os.SameFile(locked, current) {
+		unlock()
+		return nil, false, nil
+	}
+	return unlock, true, nil
+}
+
+// removeLock removes given lock file unless it is held by another process
+func removeLock(path string) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	if unlock, ok, err := tryLock(path); err == nil && ok {
+		os.Remove(path)
+		unlock()
+	}
+}
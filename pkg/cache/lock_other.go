@@ -0,0 +1,13 @@
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package cache
+
+import "os"
+
+func lockFile(file *os.File, block bool) (bool, error) {
+	return true, nil // advisory locking not supported
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryCache stores entries in memory (e.g. for long-lived hosts and tests)
+type MemoryCache struct {
+	mutex   sync.RWMutex
+	entries map[string]Entry
+	locks   map[string]bool
+}
+
+// NewMemoryCache creates an empty MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]Entry), locks: make(map[string]bool)}
+}
+
+// Load returns the entry for given id or ErrNotExist
+func (m *MemoryCache) Load(id string) (Entry, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entry, ok := m.entries[id]
+	if !ok {
+		return Entry{}, ErrNotExist
+	}
+	entry.Content = append([]byte{}, entry.Content...)
+	return entry, nil
+}
+
+// Write persists content for given id
+func (m *MemoryCache) Write(id string, content []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	m.entries[id] = Entry{ID: id, Content: append([]byte{}, content...), Size: int64(len(content)), ModTime: now, AccessTime: now}
+	return nil
+}
+
+// Delete removes the entry for given id
+func (m *MemoryCache) Delete(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.entries, id)
+	return nil
+}
+
+// List returns the ids of all entries starting with given prefix
+func (m *MemoryCache) List(prefix string) ([]string, error) {
+	return entryIDs(m.Entries(prefix))
+}
+
+// Entries returns all entries (without content) whose id starts with given prefix
+func (m *MemoryCache) Entries(prefix string) ([]Entry, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entries := make([]Entry, 0)
+	for id, entry := range m.entries {
+		if strings.HasPrefix(id, prefix) {
+			entry.Content = nil
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+// Touch marks the entry for given id as recently used
+func (m *MemoryCache) Touch(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	entry, ok := m.entries[id]
+	if !ok {
+		return ErrNotExist
+	}
+	entry.AccessTime = time.Now()
+	m.entries[id] = entry
+	return nil
+}
+
+// TryLock acquires the lock for given id without blocking
+func (m *MemoryCache) TryLock(id string) (func(), bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.locks[id] {
+		return nil, false, nil
+	}
+	m.locks[id] = true
+	return func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		delete(m.locks, id)
+	}, true, nil
+}
@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SingleFileCache stores all entries within a single file as embedded key-value store
+// Modifications are guarded by an advisory lock on a `.lock` file next to it and refreshes by `.lock` files within a `.locks` directory.
+type SingleFileCache struct {
+	File  string
+	mutex sync.Mutex
+}
+
+type singleFileEntry struct {
+	Content    []byte
+	ModTime    time.Time
+	AccessTime time.Time
+}
+
+func (e singleFileEntry) entry(id string) Entry {
+	return Entry{ID: id, Content: e.Content, Size: int64(len(e.Content)), ModTime: e.ModTime, AccessTime: e.AccessTime}
+}
+
+// NewSingleFileCache creates a SingleFileCache using given file
+func NewSingleFileCache(file string) *SingleFileCache {
+	return &SingleFileCache{File: file}
+}
+
+func (s *SingleFileCache) read() (map[string]singleFileEntry, error) {
+	entries := make(map[string]singleFileEntry)
+	content, err := ioutil.ReadFile(s.File)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return make(map[string]singleFileEntry), nil // start over if corrupt
+	}
+	return entries, nil
+}
+
+func (s *SingleFileCache) write(entries map[string]singleFileEntry) error {
+	content, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return writeFile(s.File, content)
+}
+
+// modify applies given function to the entries while holding the lock for other processes
+func (s *SingleFileCache) modify(f func(entries map[string]singleFileEntry) bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.File), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.File+lockSuffix, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := lockFile(file, true); err != nil {
+		return err
+	}
+	defer unlockFile(file)
+
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+	if !f(entries) {
+		return nil
+	}
+	return s.write(entries)
+}
+
+// Load returns the entry for given id or ErrNotExist
+func (s *SingleFileCache) Load(id string) (Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entries, err := s.read()
+	if err != nil {
+		return Entry{}, err
+	}
+	entry, ok := entries[id]
+	if !ok {
+		return Entry{}, ErrNotExist
+	}
+	return entry.entry(id), nil
+}
+
+// Write persists content for given id
+func (s *SingleFileCache) Write(id string, content []byte) error {
+	return s.modify(func(entries map[string]singleFileEntry) bool {
+		now := time.Now()
+		entries[id] = singleFileEntry{Content: content, ModTime: now, AccessTime: now}
+		return true
+	})
+}
+
+// Delete removes the entry for given id (the lock file is kept while held by another process)
+func (s *SingleFileCache) Delete(id string) error {
+	err := s.modify(func(entries map[string]singleFileEntry) bool {
+		if _, ok := entries[id]; !ok {
+			return false
+		}
+		delete(entries, id)
+		return true
+	})
+	if err == nil {
+		removeLock(s.lockPath(id))
+	}
+	return err
+}
+
+// List returns the ids of all entries starting with given prefix
+func (s *SingleFileCache) List(prefix string) ([]string, error) {
+	return entryIDs(s.Entries(prefix))
+}
+
+// Entries returns all entries (without content) whose id starts with given prefix
+func (s *SingleFileCache) Entries(prefix string) ([]Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entries, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	listed := make([]Entry, 0, len(entries))
+	for id, entry := range entries {
+		if strings.HasPrefix(id, prefix) {
+			e := entry.entry(id)
+			e.Content = nil
+			listed = append(listed, e)
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].ID < listed[j].ID })
+	return listed, nil
+}
+
+// Touch marks the entry for given id as recently used
+func (s *SingleFileCache) Touch(id string) error {
+	exists := true
+	err := s.modify(func(entries map[string]singleFileEntry) bool {
+		entry, ok := entries[id]
+		if !ok {
+			exists = false
+			return false
+		}
+		entry.AccessTime = time.Now()
+		entries[id] = entry
+		return true
+	})
+	if err == nil && !exists {
+		return ErrNotExist
+	}
+	return err
+}
+
+func (s *SingleFileCache) lockPath(id string) string {
+	return filepath.Join(s.File+".locks", filepath.FromSlash(id)+lockSuffix)
+}
+
+// TryLock acquires an advisory lock for given id without blocking
+func (s *SingleFileCache) TryLock(id string) (func(), bool, error) {
+	path := s.lockPath(id)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, false, err
+	}
+	return tryLock(path)
+}
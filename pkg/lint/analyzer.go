@@ -0,0 +1,358 @@
+// Package lint provides an analyzer for carapace registration mistakes (see cmd/carapace-lint)
+package lint
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const (
+	carapacePath = "github.com/rsteube/carapace"
+	cobraPath    = "github.com/spf13/cobra"
+	pflagPath    = "github.com/spf13/pflag"
+)
+
+// Analyzer reports carapace registration mistakes
+//   carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{"unknown": ...}) // flag not declared on cmd
+//   carapace.ActionValues("a").Invoke(c).Prefix("x").Filter(...)         // Filter after Prefix/Suffix
+var Analyzer = &analysis.Analyzer{
+	Name: "carapace",
+	Doc: `reports carapace registration mistakes
+
+Flag names in ActionMap literals passed to carapace.Gen(cmd).FlagCompletion
+are checked against the flags declared on the same command variable (skipped
+if these can't be resolved statically). Calling Filter on an InvokedAction
+after Prefix or Suffix is reported as these alter the values being filtered.`,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// command contains the flags declared on a command variable
+type command struct {
+	flags      map[string]bool
+	unresolved bool // flags can't be determined statically
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	commands := make(map[types.Object]*command)
+	cmdFor := func(obj types.Object) *command {
+		c, ok := commands[obj]
+		if !ok {
+			c = &command{flags: make(map[string]bool)}
+			commands[obj] = c
+		}
+		return c
+	}
+
+	// flagsets assigned to variables (e.g. `flags := cmd.Flags()`)
+	flagsets := make(map[types.Object]types.Object)
+
+	type registration struct {
+		cmd  types.Object
+		name string
+		key  ast.Expr
+	}
+	registrations := make([]registration, 0)
+
+	inspect.WithStack([]ast.Node{(*ast.AssignStmt)(nil), (*ast.ValueSpec)(nil), (*ast.Ident)(nil), (*ast.CallExpr)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for index, lhs := range n.Lhs {
+				var rhs ast.Expr
+				if len(n.Rhs) == len(n.Lhs) {
+					rhs = n.Rhs[index]
+				}
+				assign(pass, lhs, rhs, cmdFor, flagsets)
+			}
+
+		case *ast.ValueSpec:
+			for index, name := range n.Names {
+				var rhs ast.Expr
+				if len(n.Values) == len(n.Names) {
+					rhs = n.Values[index]
+				}
+				assign(pass, name, rhs, cmdFor, flagsets)
+			}
+
+		case *ast.Ident:
+			if obj := pass.TypesInfo.Uses[n]; obj != nil && isCommandVar(pass, obj) && escapes(pass, n, stack) {
+				cmdFor(obj).unresolved = true // e.g. passed to a function adding flags
+			}
+
+		case *ast.CallExpr:
+			selector, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			fn, ok := pass.TypesInfo.Uses[selector.Sel].(*types.Func)
+			if !ok {
+				return true
+			}
+
+			switch {
+			case isMethod(fn, pflagPath, "FlagSet"):
+				cmd := flagsetCommand(pass, selector.X, flagsets)
+				if cmd == nil {
+					return true
+				}
+				if name, ok := declaredFlag(pass, fn, n); ok {
+					cmdFor(cmd).flags[name] = true
+				} else if isFlagDeclaration(fn) || fn.Name() == "AddFlag" || fn.Name() == "AddFlagSet" || fn.Name() == "AddGoFlag" || fn.Name() == "AddGoFlagSet" {
+					cmdFor(cmd).unresolved = true
+				}
+
+			case isMethod(fn, carapacePath, "Carapace") && fn.Name() == "FlagCompletion" && len(n.Args) == 1:
+				cmd := genCommand(pass, selector.X)
+				literal, ok := n.Args[0].(*ast.CompositeLit)
+				if cmd == nil || !ok {
+					return true
+				}
+				for _, elt := range literal.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if name, ok := constantString(pass, kv.Key); ok {
+							registrations = append(registrations, registration{cmd: cmd, name: name, key: kv.Key})
+						}
+					}
+				}
+
+			case isMethod(fn, carapacePath, "InvokedAction") && fn.Name() == "Filter":
+				if altered := alteredBefore(pass, selector.X); altered != "" {
+					pass.Reportf(selector.Sel.Pos(), "Filter after %v: values are altered before being filtered (call Filter first)", altered)
+				}
+			}
+		}
+		return true
+	})
+
+	for _, r := range registrations {
+		if c := commands[r.cmd]; c != nil && !c.unresolved && !c.flags[r.name] {
+			pass.Reportf(r.key.Pos(), "unknown flag %#v for %v", r.name, r.cmd.Name())
+		}
+	}
+	return nil, nil
+}
+
+// assign tracks assignments to command and flagset variables
+func assign(pass *analysis.Pass, lhs ast.Expr, rhs ast.Expr, cmdFor func(types.Object) *command, flagsets map[types.Object]types.Object) {
+	ident, ok := lhs.(*ast.Ident)
+	if !ok {
+		return
+	}
+	obj := pass.TypesInfo.Defs[ident]
+	if obj == nil {
+		obj = pass.TypesInfo.Uses[ident]
+	}
+	if obj == nil {
+		return
+	}
+
+	switch {
+	case isCommandVar(pass, obj):
+		if !isCommandLiteral(rhs) {
+			cmdFor(obj).unresolved = true // e.g. created by a function
+		} else {
+			cmdFor(obj)
+		}
+	case isNamed(obj.Type(), pflagPath, "FlagSet"):
+		if cmd := flagsetCommand(pass, rhs, flagsets); cmd != nil {
+			flagsets[obj] = cmd
+		}
+	}
+}
+
+// isCommandVar checks if given object is a *cobra.Command variable of the current package
+func isCommandVar(pass *analysis.Pass, obj types.Object) bool {
+	_, ok := obj.(*types.Var)
+	return ok && obj.Pkg() == pass.Pkg && isNamed(obj.Type(), cobraPath, "Command")
+}
+
+// isCommandLiteral checks for `&cobra.Command{...}`
+func isCommandLiteral(expr ast.Expr) bool {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		_, ok = unary.X.(*ast.CompositeLit)
+		return ok
+	}
+	return false
+}
+
+// escapes checks if a command variable is used in a way its flags might be altered elsewhere
+func escapes(pass *analysis.Pass, ident *ast.Ident, stack []ast.Node) bool {
+	if len(stack) < 2 {
+		return false
+	}
+	switch parent := stack[len(stack)-2].(type) {
+	case *ast.CallExpr:
+		for _, arg := range parent.Args {
+			if arg == ident {
+				return !isCarapace(pass, parent) && !isAddCommand(pass, parent)
+			}
+		}
+	case *ast.AssignStmt:
+		for _, rhs := range parent.Rhs {
+			if rhs == ident {
+				return true
+			}
+		}
+	case *ast.ValueSpec:
+		for _, value := range parent.Values {
+			if value == ident {
+				return true
+			}
+		}
+	case *ast.CompositeLit, *ast.KeyValueExpr, *ast.ReturnStmt:
+		return true
+	}
+	return false
+}
+
+// flagsetCommand returns the command variable of `cmd.Flags()`, `cmd.PersistentFlags()` or a variable assigned from these
+func flagsetCommand(pass *analysis.Pass, expr ast.Expr, flagsets map[types.Object]types.Object) types.Object {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return flagsets[pass.TypesInfo.Uses[expr]]
+	case *ast.CallExpr:
+		selector, ok := expr.Fun.(*ast.SelectorExpr)
+		if !ok || (selector.Sel.Name != "Flags" && selector.Sel.Name != "PersistentFlags") {
+			return nil
+		}
+		if fn, ok := pass.TypesInfo.Uses[selector.Sel].(*types.Func); ok && isMethod(fn, cobraPath, "Command") {
+			return commandVar(pass, selector.X)
+		}
+	}
+	return nil
+}
+
+// genCommand returns the command variable of `carapace.Gen(cmd)`
+func genCommand(pass *analysis.Pass, expr ast.Expr) types.Object {
+	if call, ok := expr.(*ast.CallExpr); ok && isGen(pass, call) && len(call.Args) == 1 {
+		return commandVar(pass, call.Args[0])
+	}
+	return nil
+}
+
+func commandVar(pass *analysis.Pass, expr ast.Expr) types.Object {
+	var ident *ast.Ident
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		ident = expr
+	case *ast.SelectorExpr:
+		ident = expr.Sel
+	default:
+		return nil
+	}
+	if obj := pass.TypesInfo.Uses[ident]; obj != nil && isCommandVar(pass, obj) {
+		return obj
+	}
+	return nil
+}
+
+func isGen(pass *analysis.Pass, call *ast.CallExpr) bool {
+	fn := calledFunc(pass, call)
+	return fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == carapacePath && fn.Name() == "Gen"
+}
+
+// isCarapace checks for calls to carapace functions (e.g. `carapace.Gen(cmd)`) which don't declare flags
+func isCarapace(pass *analysis.Pass, call *ast.CallExpr) bool {
+	fn := calledFunc(pass, call)
+	return fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == carapacePath
+}
+
+func isAddCommand(pass *analysis.Pass, call *ast.CallExpr) bool {
+	fn := calledFunc(pass, call)
+	return fn != nil && isMethod(fn, cobraPath, "Command") && fn.Name() == "AddCommand"
+}
+
+func calledFunc(pass *analysis.Pass, call *ast.CallExpr) *types.Func {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return nil
+	}
+	fn, _ := pass.TypesInfo.Uses[ident].(*types.Func)
+	return fn
+}
+
+// isFlagDeclaration checks if given FlagSet method declares a flag (has `name` and `usage` parameters)
+func isFlagDeclaration(fn *types.Func) bool {
+	return nameParam(fn) >= 0
+}
+
+func nameParam(fn *types.Func) int {
+	params := fn.Type().(*types.Signature).Params()
+	if params.Len() < 2 || params.At(params.Len()-1).Name() != "usage" {
+		return -1
+	}
+	for i := 0; i < params.Len(); i++ {
+		if params.At(i).Name() == "name" {
+			return i
+		}
+	}
+	return -1
+}
+
+// declaredFlag returns the name of the flag declared by given FlagSet method call
+func declaredFlag(pass *analysis.Pass, fn *types.Func, call *ast.CallExpr) (string, bool) {
+	if index := nameParam(fn); index >= 0 && index < len(call.Args) {
+		return constantString(pass, call.Args[index])
+	}
+	return "", false
+}
+
+func constantString(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	if tv, ok := pass.TypesInfo.Types[expr]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+		return constant.StringVal(tv.Value), true
+	}
+	return "", false
+}
+
+// alteredBefore returns `Prefix` or `Suffix` if called within given chain of InvokedAction methods
+func alteredBefore(pass *analysis.Pass, expr ast.Expr) string {
+	for {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return ""
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return ""
+		}
+		fn, ok := pass.TypesInfo.Uses[selector.Sel].(*types.Func)
+		if !ok || !isMethod(fn, carapacePath, "InvokedAction") {
+			return ""
+		}
+		if fn.Name() == "Prefix" || fn.Name() == "Suffix" {
+			return fn.Name()
+		}
+		expr = selector.X
+	}
+}
+
+// isMethod checks if given function is a method of the named type (or a pointer to it)
+func isMethod(fn *types.Func, path, name string) bool {
+	recv := fn.Type().(*types.Signature).Recv()
+	return recv != nil && isNamed(recv.Type(), path, name)
+}
+
+// isNamed checks if given type is the named type (or a pointer to it)
+func isNamed(t types.Type, path, name string) bool {
+	if pointer, ok := t.(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == path && named.Obj().Name() == name
+}
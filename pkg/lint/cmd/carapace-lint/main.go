@@ -0,0 +1,13 @@
+// carapace-lint reports carapace registration mistakes
+//   go install github.com/rsteube/carapace/pkg/lint/cmd/carapace-lint@latest
+//   go vet -vettool=$(which carapace-lint) ./...
+package main
+
+import (
+	"github.com/rsteube/carapace/pkg/lint"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(lint.Analyzer)
+}
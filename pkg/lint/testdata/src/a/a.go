@@ -0,0 +1,72 @@
+package a
+
+import (
+	"github.com/rsteube/carapace"
+	"github.com/spf13/cobra"
+)
+
+const nameConst = "const"
+
+var rootCmd = &cobra.Command{Use: "root"}
+
+var subCmd = &cobra.Command{Use: "sub"}
+
+var dynamicCmd = newCmd()
+
+var helperCmd = &cobra.Command{Use: "helper"}
+
+func newCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "dynamic"}
+	cmd.Flags().String("dynamic", "", "")
+	return cmd
+}
+
+func addFlags(cmd *cobra.Command) {
+	cmd.Flags().String("helper", "", "")
+}
+
+func init() {
+	var s string
+	rootCmd.Flags().String("string", "", "")
+	rootCmd.Flags().StringP("short", "s", "", "")
+	rootCmd.Flags().StringVar(&s, "var", "", "")
+	rootCmd.Flags().String(nameConst, "", "")
+	rootCmd.Flags().MarkHidden("marked")
+	rootCmd.PersistentFlags().Bool("persistent", false, "")
+	flags := subCmd.Flags()
+	flags.String("aliased", "", "")
+	rootCmd.AddCommand(subCmd, dynamicCmd, helperCmd)
+	addFlags(helperCmd)
+	carapace.Smoke(nil, rootCmd)
+
+	carapace.Gen(rootCmd).FlagCompletion(carapace.ActionMap{
+		"string":     carapace.ActionValues(),
+		"short":      carapace.ActionValues(),
+		"var":        carapace.ActionValues(),
+		"const":      carapace.ActionValues(),
+		"persistent": carapace.ActionValues(),
+		"marked":     carapace.ActionValues(), // want `unknown flag "marked" for rootCmd`
+		"unknown":    carapace.ActionValues(), // want `unknown flag "unknown" for rootCmd`
+	})
+
+	carapace.Gen(subCmd).FlagCompletion(carapace.ActionMap{
+		"aliased":    carapace.ActionValues(),
+		"persistent": carapace.ActionValues(), // want `unknown flag "persistent" for subCmd`
+	})
+
+	carapace.Gen(dynamicCmd).FlagCompletion(carapace.ActionMap{
+		"unknown": carapace.ActionValues(), // not resolvable
+	})
+
+	carapace.Gen(helperCmd).FlagCompletion(carapace.ActionMap{
+		"unknown": carapace.ActionValues(), // not resolvable
+	})
+}
+
+func filter(c carapace.Context) {
+	a := carapace.ActionValues("a", "b").Invoke(c)
+	a.Filter([]string{"a"}).Prefix("x")
+	a.Prefix("x").Filter([]string{"a"})          // want `Filter after Prefix: values are altered before being filtered`
+	a.Suffix("x").Merge(a).Filter([]string{"a"}) // want `Filter after Suffix: values are altered before being filtered`
+	carapace.ActionValues().Invoke(c).Prefix("x").ToA().Invoke(c).Filter(nil)
+}
@@ -0,0 +1,28 @@
+package carapace
+
+import "github.com/spf13/cobra"
+
+type Action struct{}
+
+type ActionMap map[string]Action
+
+type Context struct{}
+
+type Carapace struct{}
+
+type InvokedAction struct{ Action }
+
+func Gen(cmd *cobra.Command) *Carapace                       { return nil }
+func (c Carapace) FlagCompletion(actions ActionMap)          {}
+func (c Carapace) PositionalCompletion(action ...Action)     {}
+func ActionValues(values ...string) Action                   { return Action{} }
+func (a Action) Invoke(c Context) InvokedAction              { return InvokedAction{} }
+func (a InvokedAction) Filter(values []string) InvokedAction { return a }
+func (a InvokedAction) Prefix(prefix string) InvokedAction   { return a }
+func (a InvokedAction) Suffix(suffix string) InvokedAction   { return a }
+func (a InvokedAction) Merge(others ...InvokedAction) InvokedAction {
+	return a
+}
+func (a InvokedAction) ToA() Action { return a.Action }
+
+func Smoke(t interface{}, cmd *cobra.Command) {}
@@ -0,0 +1,18 @@
+package pflag
+
+type FlagSet struct{}
+
+type Flag struct{}
+
+type Value interface{}
+
+func (f *FlagSet) String(name string, value string, usage string) *string { return nil }
+func (f *FlagSet) StringP(name, shorthand string, value string, usage string) *string {
+	return nil
+}
+func (f *FlagSet) StringVar(p *string, name string, value string, usage string) {}
+func (f *FlagSet) Bool(name string, value bool, usage string) *bool             { return nil }
+func (f *FlagSet) Var(value Value, name string, usage string)                   {}
+func (f *FlagSet) AddFlag(flag *Flag)                                           {}
+func (f *FlagSet) Lookup(name string) *Flag                                     { return nil }
+func (f *FlagSet) MarkHidden(name string) error                                 { return nil }
@@ -0,0 +1,54 @@
+// Package match provides strategies to match completion values against the word currently being completed
+package match
+
+import (
+	"strings"
+)
+
+// Matcher reports whether s matches the word currently being completed
+type Matcher func(s, word string) bool
+
+// Prefix matches values starting with the word (default)
+//   match.Prefix("eu-prod-cluster", "eu") // true
+func Prefix(s, word string) bool {
+	return strings.HasPrefix(s, word)
+}
+
+// CaseInsensitive matches values starting with the word ignoring case
+//   match.CaseInsensitive("EU-prod-cluster", "eu") // true
+func CaseInsensitive(s, word string) bool {
+	return strings.HasPrefix(strings.ToLower(s), strings.ToLower(word))
+}
+
+// Substring matches values containing the word
+//   match.Substring("eu-prod-cluster", "prod") // true
+func Substring(s, word string) bool {
+	return strings.Contains(s, word)
+}
+
+// Fuzzy matches values containing the characters of the word in order (ignoring case)
+//   match.Fuzzy("eu-prod-cluster", "epc") // true
+func Fuzzy(s, word string) bool {
+	runes := []rune(strings.ToLower(word))
+	for _, r := range strings.ToLower(s) {
+		if len(runes) == 0 {
+			break
+		}
+		if r == runes[0] {
+			runes = runes[1:]
+		}
+	}
+	return len(runes) == 0
+}
+
+// Match reports whether the value (or optionally its description) matches the word (prefix matches are always accepted)
+//   match.Matcher(match.Substring).Match("eu-prod-cluster", "", "cl", false) // true
+func (m Matcher) Match(value, description, word string, descriptions bool) bool {
+	if m == nil {
+		m = Prefix
+	}
+	if strings.HasPrefix(value, word) {
+		return true
+	}
+	return m(value, word) || (descriptions && m(description, word))
+}
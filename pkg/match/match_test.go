@@ -0,0 +1,57 @@
+package match
+
+import (
+	"testing"
+)
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		matcher  Matcher
+		s        string
+		word     string
+		expected bool
+	}{
+		{Prefix, "eu-prod-cluster", "eu", true},
+		{Prefix, "eu-prod-cluster", "prod", false},
+		{CaseInsensitive, "EU-prod-cluster", "eu-P", true},
+		{CaseInsensitive, "eu-prod-cluster", "prod", false},
+		{Substring, "eu-prod-cluster", "prod", true},
+		{Substring, "eu-prod-cluster", "PROD", false},
+		{Fuzzy, "eu-prod-cluster", "epc", true},
+		{Fuzzy, "eu-prod-cluster", "EPC", true},
+		{Fuzzy, "eu-prod-cluster", "cpe", false},
+	}
+
+	for _, test := range tests {
+		if actual := test.matcher(test.s, test.word); actual != test.expected {
+			t.Errorf("expected %v for %#v matching %#v", test.expected, test.s, test.word)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		matcher      Matcher
+		value        string
+		description  string
+		word         string
+		descriptions bool
+		expected     bool
+	}{
+		{nil, "first,second", "", "first,se", false, true},
+		{nil, "first,second", "", "first,co", false, false},
+		{Substring, "first,second", "", "co", false, true},
+		{Substring, "abc", "", "ac", false, false},
+		{Fuzzy, "eu-prod-cluster", "", "prc", false, true},
+		{Substring, "second", "running", "run", false, false},
+		{Substring, "second", "running", "run", true, true},
+		{Prefix, "second", "running", "run", true, true},
+		{Substring, "eu-prod-cluster", "", "eu-cl", false, false}, // segments are only skipped for ActionMultiParts (see carapace)
+	}
+
+	for _, test := range tests {
+		if actual := test.matcher.Match(test.value, test.description, test.word, test.descriptions); actual != test.expected {
+			t.Errorf("expected %v for %#v (%#v) matching %#v", test.expected, test.value, test.description, test.word)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package style
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LsColors contains styles parsed from the LS_COLORS environment variable
+type LsColors struct {
+	types      map[string]string
+	extensions []extensionStyle
+}
+
+type extensionStyle struct {
+	suffix string
+	style  string
+}
+
+// ParseLsColors parses given LS_COLORS value (e.g. `di=01;34:ln=01;36:*.tar=01;31`)
+func ParseLsColors(s string) LsColors {
+	l := LsColors{types: make(map[string]string)}
+	for _, entry := range strings.Split(s, ":") {
+		splitted := strings.SplitN(entry, "=", 2)
+		if len(splitted) != 2 || splitted[0] == "" {
+			continue
+		}
+		if strings.HasPrefix(splitted[0], "*") {
+			l.extensions = append(l.extensions, extensionStyle{suffix: strings.ToLower(splitted[0][1:]), style: FromSGR(splitted[1])})
+		} else {
+			l.types[splitted[0]] = splitted[1]
+		}
+	}
+	return l
+}
+
+func (l LsColors) style(key string) (string, bool) {
+	if code, ok := l.types[key]; ok {
+		return FromSGR(code), true
+	}
+	return "", false
+}
+
+// ForPath returns the style for given path (follows symlinks if `ln=target` is set)
+func (l LsColors) ForPath(path string) string {
+	info, err := os.Lstat(path)
+	if err != nil {
+		s, _ := l.style("mi")
+		return s
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Stat(path)
+		if err != nil {
+			if s, ok := l.style("or"); ok {
+				return s
+			}
+			s, _ := l.style("ln")
+			return s
+		}
+		if l.types["ln"] != "target" {
+			s, _ := l.style("ln")
+			return s
+		}
+		info = target
+	}
+	return l.forInfo(path, info)
+}
+
+func (l LsColors) forInfo(path string, info os.FileInfo) string {
+	mode := info.Mode()
+	key := ""
+	switch {
+	case mode.IsDir():
+		switch {
+		case mode&os.ModeSticky != 0 && mode&0002 != 0:
+			key = "tw"
+		case mode&0002 != 0:
+			key = "ow"
+		case mode&os.ModeSticky != 0:
+			key = "st"
+		default:
+			key = "di"
+		}
+	case mode&os.ModeNamedPipe != 0:
+		key = "pi"
+	case mode&os.ModeSocket != 0:
+		key = "so"
+	case mode&os.ModeDevice != 0 && mode&os.ModeCharDevice != 0:
+		key = "cd"
+	case mode&os.ModeDevice != 0:
+		key = "bd"
+	case mode&os.ModeSetuid != 0:
+		key = "su"
+	case mode&os.ModeSetgid != 0:
+		key = "sg"
+	case mode&0111 != 0:
+		key = "ex"
+	}
+
+	if key != "" {
+		if s, ok := l.style(key); ok {
+			return s
+		}
+		if key == "tw" || key == "ow" || key == "st" {
+			if s, ok := l.style("di"); ok {
+				return s
+			}
+		}
+		if key != "su" && key != "sg" && key != "ex" {
+			return ""
+		}
+	}
+
+	lowered := strings.ToLower(path)
+	for _, e := range l.extensions {
+		if strings.HasSuffix(lowered, e.suffix) {
+			return e.style
+		}
+	}
+	s, _ := l.style("fi")
+	return s
+}
+
+// FromSGR converts Select Graphic Rendition parameters to a style
+//   style.FromSGR("01;34") // "bold blue"
+func FromSGR(sgr string) string {
+	codes := strings.Split(sgr, ";")
+	keywords := make([]string, 0, len(codes))
+	for index := 0; index < len(codes); index++ {
+		code, err := strconv.Atoi(codes[index])
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case code >= 30 && code <= 37:
+			keywords = append(keywords, colors[code-30])
+		case code >= 40 && code <= 47:
+			keywords = append(keywords, "bg-"+colors[code-40])
+		case code >= 90 && code <= 97:
+			keywords = append(keywords, "bright-"+colors[code-90])
+		case code >= 100 && code <= 107:
+			keywords = append(keywords, "bg-bright-"+colors[code-100])
+		case (code == 38 || code == 48) && index+2 < len(codes) && codes[index+1] == "5":
+			keyword := "color" + codes[index+2]
+			if code == 48 {
+				keyword = "bg-" + keyword
+			}
+			keywords = append(keywords, keyword)
+			index += 2
+		case (code == 38 || code == 48) && index+4 < len(codes) && codes[index+1] == "2":
+			keyword := "#"
+			for _, c := range codes[index+2 : index+5] {
+				value, _ := strconv.Atoi(c)
+				keyword += fmt.Sprintf("%02x", value)
+			}
+			if code == 48 {
+				keyword = "bg-" + keyword
+			}
+			keywords = append(keywords, keyword)
+			index += 4
+		default:
+			for keyword, attribute := range attributes {
+				if attribute == strconv.Itoa(code) {
+					keywords = append(keywords, keyword)
+				}
+			}
+		}
+	}
+	return Of(keywords...)
+}
@@ -0,0 +1,61 @@
+package style
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFromSGR(t *testing.T) {
+	for sgr, expected := range map[string]string{
+		"01;34":          "bold blue",
+		"00;91":          "bright-red",
+		"4;42":           "underlined bg-green",
+		"38;5;208":       "color208",
+		"48;2;255;135;0": "bg-#ff8700",
+		"":               "",
+	} {
+		if actual := FromSGR(sgr); actual != expected {
+			t.Errorf("%v: expected %#v but was %#v", sgr, expected, actual)
+		}
+		if expected != "" {
+			if roundtrip := FromSGR(SGR(expected)); roundtrip != expected {
+				t.Errorf("%v: roundtrip failed: %#v", sgr, roundtrip)
+			}
+		}
+	}
+}
+
+func TestLsColors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "carapace-lscolors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Mkdir(filepath.Join(dir, "dir"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "archive.TAR"), []byte{}, 0644)
+	ioutil.WriteFile(filepath.Join(dir, "script.sh"), []byte{}, 0755)
+	ioutil.WriteFile(filepath.Join(dir, "plain.txt"), []byte{}, 0644)
+	os.Symlink(filepath.Join(dir, "dir"), filepath.Join(dir, "link"))
+	os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "broken"))
+
+	l := ParseLsColors("di=01;34:ln=01;36:or=31:ex=01;32:*.tar=01;31")
+	for name, expected := range map[string]string{
+		"dir":         "bold blue",
+		"archive.TAR": "bold red",
+		"script.sh":   "bold green",
+		"plain.txt":   "",
+		"link":        "bold cyan",
+		"broken":      "red",
+	} {
+		if actual := l.ForPath(filepath.Join(dir, name)); actual != expected {
+			t.Errorf("%v: expected %#v but was %#v", name, expected, actual)
+		}
+	}
+
+	if actual := ParseLsColors("di=01;34:ln=target").ForPath(filepath.Join(dir, "link")); actual != "bold blue" {
+		t.Errorf("link: expected target style but was %#v", actual)
+	}
+}
@@ -0,0 +1,133 @@
+// Package style provides styles for completion values
+package style
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Styles are space separated keywords compatible to elvish styled text (e.g. "red bold").
+// Besides the named ones 256 colors ("color208") and true colors ("#ff8700") are supported as well.
+const (
+	Default = ""
+
+	Black   = "black"
+	Red     = "red"
+	Green   = "green"
+	Yellow  = "yellow"
+	Blue    = "blue"
+	Magenta = "magenta"
+	Cyan    = "cyan"
+	White   = "white"
+
+	BrightBlack   = "bright-black"
+	BrightRed     = "bright-red"
+	BrightGreen   = "bright-green"
+	BrightYellow  = "bright-yellow"
+	BrightBlue    = "bright-blue"
+	BrightMagenta = "bright-magenta"
+	BrightCyan    = "bright-cyan"
+	BrightWhite   = "bright-white"
+
+	BgBlack   = "bg-black"
+	BgRed     = "bg-red"
+	BgGreen   = "bg-green"
+	BgYellow  = "bg-yellow"
+	BgBlue    = "bg-blue"
+	BgMagenta = "bg-magenta"
+	BgCyan    = "bg-cyan"
+	BgWhite   = "bg-white"
+
+	BgBrightBlack   = "bg-bright-black"
+	BgBrightRed     = "bg-bright-red"
+	BgBrightGreen   = "bg-bright-green"
+	BgBrightYellow  = "bg-bright-yellow"
+	BgBrightBlue    = "bg-bright-blue"
+	BgBrightMagenta = "bg-bright-magenta"
+	BgBrightCyan    = "bg-bright-cyan"
+	BgBrightWhite   = "bg-bright-white"
+
+	Bold       = "bold"
+	Dim        = "dim"
+	Italic     = "italic"
+	Underlined = "underlined"
+	Blink      = "blink"
+	Inverse    = "inverse"
+)
+
+// Of combines different styles
+//   style.Of(style.Red, style.Bold) // "red bold"
+func Of(s ...string) string {
+	filtered := make([]string, 0, len(s))
+	for _, style := range s {
+		if style = strings.TrimSpace(style); style != "" {
+			filtered = append(filtered, style)
+		}
+	}
+	return strings.Join(filtered, " ")
+}
+
+var colors = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+var attributes = map[string]string{
+	Bold:       "1",
+	Dim:        "2",
+	Italic:     "3",
+	Underlined: "4",
+	Blink:      "5",
+	Inverse:    "7",
+}
+
+// SGR returns the Select Graphic Rendition parameters for given style
+//   style.SGR("red bold") // "31;1"
+func SGR(s string) string {
+	codes := make([]string, 0)
+	for _, keyword := range strings.Fields(s) {
+		if code, ok := sgrCode(keyword); ok {
+			codes = append(codes, code)
+		}
+	}
+	return strings.Join(codes, ";")
+}
+
+func sgrCode(keyword string) (string, bool) {
+	if code, ok := attributes[keyword]; ok {
+		return code, true
+	}
+
+	base := 30
+	if strings.HasPrefix(keyword, "bg-") {
+		base = 40
+		keyword = strings.TrimPrefix(keyword, "bg-")
+	} else {
+		keyword = strings.TrimPrefix(keyword, "fg-")
+	}
+
+	if strings.HasPrefix(keyword, "color") { // 256 colors (e.g. "color208")
+		if index, err := strconv.Atoi(strings.TrimPrefix(keyword, "color")); err == nil && index >= 0 && index < 256 {
+			return fmt.Sprintf("%v;5;%v", base+8, index), true
+		}
+		return "", false
+	}
+
+	if strings.HasPrefix(keyword, "#") && len(keyword) == 7 { // true colors (e.g. "#ff8700")
+		rgb, err := strconv.ParseUint(keyword[1:], 16, 32)
+		if err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("%v;2;%v;%v;%v", base+8, rgb>>16, (rgb>>8)&0xff, rgb&0xff), true
+	}
+
+	if strings.HasPrefix(keyword, "bright-") {
+		base += 60
+		keyword = strings.TrimPrefix(keyword, "bright-")
+	}
+
+	for index, color := range colors {
+		if color == keyword {
+			return strconv.Itoa(base + index), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,179 @@
+package carapace
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rsteube/carapace/internal/uid"
+	"github.com/spf13/cobra"
+)
+
+// smokeTimeout is the maximum duration of a single invocation during Smoke (Context is cancelled afterwards)
+var smokeTimeout = 10 * time.Second
+
+// smokeGrace is the duration a timed out callback has to return after its Context was cancelled
+var smokeGrace = 100 * time.Millisecond
+
+// smokeDividers are used to derive multi-part prefixes from completed values
+const smokeDividers = "/:,=.@"
+
+// SmokeResult contains the outcome of invoking a registered Action with a representative Context
+type SmokeResult struct {
+	UID      string // uid of the flag or positional argument
+	Value    string // CallbackValue of the Context
+	Duration time.Duration
+	Values   int // amount of values (exclusive messages)
+	Messages []string
+	Panic    string // recovered panic with stack trace
+	Running  bool   // callback kept running after the timeout (Context was not honored)
+}
+
+type smokeT interface {
+	Error(args ...interface{})
+	Helper()
+	Log(args ...interface{})
+}
+
+// Smoke invokes all Actions registered for given command and its subcommands (see SmokeResults).
+// Panics and callbacks still running after the timeout are reported as errors while messages, durations and empty results are logged.
+//   func TestSmoke(t *testing.T) {
+//       carapace.Smoke(t, rootCmd)
+//   }
+func Smoke(t smokeT, cmd *cobra.Command) {
+	t.Helper()
+	for _, result := range SmokeResults(cmd) {
+		switch {
+		case result.Panic != "":
+			t.Error(fmt.Sprintf("%v [%#v]: panic: %v", result.UID, result.Value, result.Panic))
+		case result.Running:
+			t.Error(fmt.Sprintf("%v [%#v]: still running after %v (callback does not honor Context)", result.UID, result.Value, smokeTimeout))
+		case len(result.Messages) > 0:
+			t.Log(fmt.Sprintf("%v [%#v]: %v (%v)", result.UID, result.Value, strings.Join(result.Messages, ", "), result.Duration))
+		case result.Values == 0:
+			t.Log(fmt.Sprintf("%v [%#v]: no values (%v)", result.UID, result.Value, result.Duration))
+		default:
+			t.Log(fmt.Sprintf("%v [%#v]: %v values (%v)", result.UID, result.Value, result.Values, result.Duration))
+		}
+	}
+}
+
+// SmokeResults invokes the flag, positional and positionalAny Actions registered for given command and its subcommands.
+// Each one is invoked with an empty value, a partial value and multi-part prefixes derived from the values returned.
+// Invocations exceeding the timeout are cancelled through Context (e.g. ActionExecCommand kills the process),
+// but goroutines of callbacks ignoring it can't be stopped and keep running for the rest of the test binary.
+func SmokeResults(cmd *cobra.Command) []SmokeResult {
+	results := make([]SmokeResult, 0)
+	if e, ok := storage[cmd]; ok {
+		names := make([]string, 0, len(e.flag))
+		for name := range e.flag {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			flagUid := fmt.Sprintf("%v##%v", uid.Command(cmd), name)
+			if flag := cmd.Flags().Lookup(name); flag != nil {
+				flagUid = uid.Flag(cmd, flag)
+			}
+			results = append(results, smokeAction(flagUid, e.flag[name], cmd, []string{})...)
+		}
+
+		for index, action := range e.positional {
+			results = append(results, smokeAction(uid.Positional(cmd, index+1), action, cmd, make([]string, index))...)
+		}
+		if e.positionalAny.callback != nil || e.positionalAny.rawValues != nil {
+			index := len(e.positional)
+			results = append(results, smokeAction(uid.Positional(cmd, index+1), e.positionalAny, cmd, make([]string, index))...)
+		}
+	}
+
+	for _, subcmd := range cmd.Commands() {
+		results = append(results, SmokeResults(subcmd)...)
+	}
+	return results
+}
+
+// smokeAction invokes given Action with representative values
+func smokeAction(id string, a Action, cmd *cobra.Command, args []string) []SmokeResult {
+	empty, invoked := smokeInvoke(id, a, cmd, args, "")
+	results := []SmokeResult{empty}
+
+	if first := smokeFirstValue(invoked); first != "" {
+		if partial := string([]rune(first)[:1]); partial != first {
+			result, _ := smokeInvoke(id, a, cmd, args, partial)
+			results = append(results, result)
+		}
+
+		prefixes := make(map[string]bool)
+		for index, r := range first {
+			if strings.ContainsRune(smokeDividers, r) && index+1 < len(first) {
+				if prefix := first[:index+1]; !prefixes[prefix] {
+					prefixes[prefix] = true
+					result, _ := smokeInvoke(id, a, cmd, args, prefix)
+					results = append(results, result)
+				}
+			}
+		}
+	}
+	return results
+}
+
+// smokeInvoke invokes given Action recovering panics
+func smokeInvoke(id string, a Action, cmd *cobra.Command, args []string, value string) (SmokeResult, InvokedAction) {
+	result := SmokeResult{UID: id, Value: value}
+
+	var mutex sync.Mutex
+	finished := make(chan struct{})
+	recovered := ActionCallback(func(c Context) (action Action) {
+		defer close(finished)
+		defer func() {
+			if r := recover(); r != nil {
+				mutex.Lock()
+				result.Panic = fmt.Sprintf("%v\n%s", r, debug.Stack())
+				mutex.Unlock()
+				action = ActionValues()
+			}
+		}()
+		return a.Invoke(c).ToA()
+	})
+
+	start := time.Now()
+	invoked := recovered.Timeout(smokeTimeout).Invoke(newContext("", cmd, value, args))
+	duration := time.Since(start)
+
+	running := false
+	select {
+	case <-finished:
+	case <-time.After(smokeGrace):
+		running = true
+	}
+
+	mutex.Lock() // a running callback might still set Panic
+	defer mutex.Unlock()
+	result.Duration = duration
+	result.Running = running
+	for _, rawValue := range invoked.rawValues {
+		switch rawValue.Display {
+		case "ERR":
+			result.Messages = append(result.Messages, rawValue.Description)
+		case "_":
+			// placeholder of a message
+		default:
+			result.Values++
+		}
+	}
+	return result, invoked
+}
+
+// smokeFirstValue returns the first value in display order (exclusive messages)
+func smokeFirstValue(invoked InvokedAction) string {
+	for _, rawValue := range invoked.orderedRawValues() {
+		if rawValue.Display != "ERR" && rawValue.Display != "_" && rawValue.Value != "" {
+			return rawValue.Value
+		}
+	}
+	return ""
+}
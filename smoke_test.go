@@ -0,0 +1,102 @@
+package carapace
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type smokeRecorder struct {
+	errors []string
+	logs   []string
+}
+
+func (r *smokeRecorder) Error(args ...interface{}) { r.errors = append(r.errors, fmt.Sprint(args...)) }
+func (r *smokeRecorder) Helper()                   {}
+func (r *smokeRecorder) Log(args ...interface{})   { r.logs = append(r.logs, fmt.Sprint(args...)) }
+
+func TestSmoke(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "smoke"}
+	rootCmd.Flags().String("panic", "", "")
+	rootCmd.Flags().String("message", "", "")
+	subCmd := &cobra.Command{Use: "sub"}
+	rootCmd.AddCommand(subCmd)
+
+	Gen(rootCmd).FlagCompletion(ActionMap{
+		"panic": ActionCallback(func(c Context) Action {
+			var m map[string]string
+			m["value"] = "assignment to nil map"
+			return ActionValues()
+		}),
+		"message": ActionMessage("not logged in"),
+	})
+	Gen(subCmd).PositionalCompletion(
+		ActionMultiParts("/", func(c Context) Action {
+			if len(c.Parts) > 0 {
+				return ActionValues(strings.Join(c.Parts, "/") + "-child")
+			}
+			return ActionValues("parent/child")
+		}),
+	)
+	Gen(subCmd).PositionalAnyCompletion(ActionValues())
+
+	results := SmokeResults(rootCmd)
+	summary := make([]string, 0, len(results))
+	for _, result := range results {
+		summary = append(summary, fmt.Sprintf("%v %#v values=%v messages=%v panic=%v", result.UID, result.Value, result.Values, result.Messages, result.Panic != ""))
+	}
+	expected := []string{
+		`_smoke##message "" values=0 messages=[not logged in] panic=false`,
+		`_smoke##panic "" values=0 messages=[] panic=true`,
+		`_smoke__sub#1 "" values=1 messages=[] panic=false`,
+		`_smoke__sub#1 "p" values=1 messages=[] panic=false`,
+		`_smoke__sub#1 "parent/" values=1 messages=[] panic=false`,
+		`_smoke__sub#2 "" values=0 messages=[] panic=false`,
+	}
+	if actual := strings.Join(summary, "\n"); actual != strings.Join(expected, "\n") {
+		t.Errorf("expected:\n%v\n\nactual:\n%v", strings.Join(expected, "\n"), actual)
+	}
+
+	r := &smokeRecorder{}
+	Smoke(r, rootCmd)
+	if len(r.errors) != 1 || !strings.HasPrefix(r.errors[0], `_smoke##panic [""]: panic: assignment to entry in nil map`) {
+		t.Errorf("unexpected errors: %v", r.errors)
+	}
+	if len(r.logs) != 5 || !strings.Contains(r.logs[0], "not logged in") || !strings.Contains(r.logs[4], "no values") {
+		t.Errorf("unexpected logs: %v", r.logs)
+	}
+}
+
+func TestSmokeTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { smokeTimeout = timeout }(smokeTimeout)
+	smokeTimeout = 10 * time.Millisecond
+
+	rootCmd := &cobra.Command{Use: "timeout"}
+	rootCmd.Flags().String("honoring", "", "")
+	rootCmd.Flags().String("ignoring", "", "")
+
+	release := make(chan struct{})
+	defer close(release)
+	Gen(rootCmd).FlagCompletion(ActionMap{
+		"honoring": ActionCallback(func(c Context) Action {
+			<-c.Done()
+			return ActionValues()
+		}),
+		"ignoring": ActionCallback(func(c Context) Action {
+			<-release
+			return ActionValues()
+		}),
+	})
+
+	r := &smokeRecorder{}
+	Smoke(r, rootCmd)
+	if len(r.errors) != 1 || !strings.HasPrefix(r.errors[0], `_timeout##ignoring [""]: still running after 10ms`) {
+		t.Errorf("unexpected errors: %v", r.errors)
+	}
+	if len(r.logs) != 1 || !strings.Contains(r.logs[0], "timeout exceeded: 10ms") {
+		t.Errorf("unexpected logs: %v", r.logs)
+	}
+}
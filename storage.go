@@ -2,8 +2,12 @@ package carapace
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/rsteube/carapace/internal/uid"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // TODO storage needs better naming and structure
@@ -12,6 +16,7 @@ type entry struct {
 	flag          ActionMap
 	positional    []Action
 	positionalAny Action
+	gen           bool // passed to Gen
 }
 
 type _storage map[*cobra.Command]*entry
@@ -45,14 +50,116 @@ func (s _storage) getPositional(cmd *cobra.Command, pos int) Action {
 // TODO implicit execution during build - go:generate possible?
 func (s _storage) check() []string {
 	errors := make([]string, 0)
+	roots := make(map[*cobra.Command]bool)
 	for cmd, entry := range s {
-		for name := range entry.flag {
+		if entry.gen {
+			roots[cmd.Root()] = true
+		}
+		if !entry.hasActions() {
+			continue
+		}
+
+		switch {
+		case cmd.Hidden:
+			errors = append(errors, fmt.Sprintf("%v: completion registered for hidden command", uid.Command(cmd)))
+		case cmd.Deprecated != "":
+			errors = append(errors, fmt.Sprintf("%v: completion registered for deprecated command", uid.Command(cmd)))
+		}
+
+		for name, action := range entry.flag {
+			flagUid := fmt.Sprintf("%v##%v", uid.Command(cmd), name)
 			if flag := cmd.LocalFlags().Lookup(name); flag == nil {
-				errors = append(errors, fmt.Sprintf("unknown flag for %s: %s\n", uid.Command(cmd), name))
+				if inherited := cmd.InheritedFlags().Lookup(name); inherited != nil {
+					errors = append(errors, fmt.Sprintf("%v: persistent flag needs to be registered on the command declaring it (%v)", flagUid, uid.Flag(cmd, inherited)))
+				} else {
+					errors = append(errors, fmt.Sprintf("%v: unknown flag", flagUid))
+				}
+			}
+			if action.invalid != "" {
+				errors = append(errors, fmt.Sprintf("%v: %v", flagUid, action.invalid))
 			}
 		}
+
+		for index, action := range entry.positional {
+			if err := acceptsArgs(cmd, index+1); err != nil {
+				errors = append(errors, fmt.Sprintf("%v: positional completion exceeds accepted arguments: %v", uid.Positional(cmd, index+1), err))
+			}
+			if action.invalid != "" {
+				errors = append(errors, fmt.Sprintf("%v: %v", uid.Positional(cmd, index+1), action.invalid))
+			}
+		}
+
+		if entry.positionalAny.callback != nil || entry.positionalAny.rawValues != nil {
+			if err := acceptsArgs(cmd, len(entry.positional)+1); err != nil {
+				errors = append(errors, fmt.Sprintf("%v: positional completion exceeds accepted arguments: %v", uid.Positional(cmd, len(entry.positional)+1), err))
+			}
+			if entry.positionalAny.invalid != "" {
+				errors = append(errors, fmt.Sprintf("%v: %v", uid.Positional(cmd, len(entry.positional)+1), entry.positionalAny.invalid))
+			}
+		}
+	}
+
+	for root := range roots {
+		errors = append(errors, s.checkGen(root)...)
+	}
+	sort.Strings(errors)
+	return errors
+}
+
+// checkGen verifies that (sub)commands with something to complete were passed to Gen
+func (s _storage) checkGen(cmd *cobra.Command) []string {
+	errors := make([]string, 0)
+	if ignoredCommand(cmd) {
+		return errors
+	}
+	if e, ok := s[cmd]; (!ok || !e.gen) && needsCompletion(cmd) {
+		errors = append(errors, fmt.Sprintf("%v: command was not passed to Gen", uid.Command(cmd)))
+	}
+	for _, subcmd := range cmd.Commands() {
+		errors = append(errors, s.checkGen(subcmd)...)
 	}
 	return errors
 }
 
+// ignoredCommand checks if given command is hidden, deprecated or provided by cobra/carapace
+func ignoredCommand(cmd *cobra.Command) bool {
+	return cmd.Hidden || cmd.Deprecated != "" || cmd.Name() == "_carapace" || cmd.Name() == "help" || cmd.Name() == "completion"
+}
+
+func (e *entry) hasActions() bool {
+	return len(e.flag) > 0 || len(e.positional) > 0 || e.positionalAny.callback != nil || e.positionalAny.rawValues != nil
+}
+
+// needsCompletion checks if given command has flags taking a value or explicitly accepts positional arguments
+func needsCompletion(cmd *cobra.Command) bool {
+	needed := false
+	cmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if !f.Hidden && takesValue(f) {
+			needed = true
+		}
+	})
+	return needed || (cmd.Args != nil && acceptsArgs(cmd, 1) == nil)
+}
+
+// takesValue checks if given flag takes a value (including optional ones like `--color[=WHEN]`)
+func takesValue(f *pflag.Flag) bool {
+	return f.NoOptDefVal == "" || (f.Value.Type() != "bool" && f.Value.Type() != "count")
+}
+
+// acceptsArgs checks if given amount of positional arguments is accepted by the Args validator of the command (if set)
+func acceptsArgs(cmd *cobra.Command, count int) error {
+	if cmd.Args == nil {
+		return nil // arbitrary args
+	}
+	placeholder := ""
+	if len(cmd.ValidArgs) > 0 {
+		placeholder = strings.SplitN(cmd.ValidArgs[0], "\t", 2)[0] // pass OnlyValidArgs
+	}
+	args := make([]string, count)
+	for index := range args {
+		args[index] = placeholder
+	}
+	return cmd.Args(cmd, args)
+}
+
 var storage = make(_storage)
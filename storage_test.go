@@ -0,0 +1,61 @@
+package carapace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestStorageCheck(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	rootCmd.PersistentFlags().String("persistent", "", "")
+	rootCmd.Flags().String("local", "", "")
+
+	exactCmd := &cobra.Command{Use: "exact", Args: cobra.ExactArgs(1)}
+	validCmd := &cobra.Command{Use: "valid", Args: cobra.OnlyValidArgs, ValidArgs: []string{"a\tdescription", "b"}}
+	hiddenCmd := &cobra.Command{Use: "hidden", Hidden: true}
+	deprecatedCmd := &cobra.Command{Use: "deprecated", Deprecated: "use other"}
+	missingCmd := &cobra.Command{Use: "missing"}
+	missingCmd.Flags().String("value", "", "")
+	boolCmd := &cobra.Command{Use: "bool"}
+	boolCmd.Flags().Bool("toggle", false, "")
+	optionalCmd := &cobra.Command{Use: "optional"}
+	optionalCmd.Flags().String("color", "", "")
+	optionalCmd.Flag("color").NoOptDefVal = "auto"
+	rootCmd.AddCommand(exactCmd, validCmd, hiddenCmd, deprecatedCmd, missingCmd, boolCmd, optionalCmd)
+
+	s := make(_storage)
+	for _, cmd := range []*cobra.Command{rootCmd, exactCmd, validCmd, hiddenCmd, deprecatedCmd} {
+		s.get(cmd).gen = true
+	}
+	s.get(rootCmd).flag = ActionMap{
+		"persistent": ActionValues(),
+		"local":      ActionValuesDescribed("a", "description", "b"),
+		"unknown":    ActionValues(),
+	}
+	s.get(exactCmd).flag = ActionMap{"persistent": ActionValues()}
+	s.get(exactCmd).positional = []Action{ActionValuesDescribed("a").Tag("values"), ActionValues()}
+	s.get(exactCmd).positionalAny = ActionValues()
+	s.get(validCmd).positional = []Action{Batch(ActionValues(), ActionStyledValues("a").Chdir("/")).ToA()}
+	s.get(hiddenCmd).positionalAny = ActionValues()
+	s.get(deprecatedCmd).positionalAny = ActionStyledValuesDescribed("a", "b")
+
+	expected := []string{
+		"_root##local: ActionValuesDescribed expects a multiple of 2 arguments (got 3)",
+		"_root##unknown: unknown flag",
+		"_root__deprecated#1: ActionStyledValuesDescribed expects a multiple of 3 arguments (got 2)",
+		"_root__deprecated: completion registered for deprecated command",
+		"_root__exact##persistent: persistent flag needs to be registered on the command declaring it (_root##persistent)",
+		"_root__exact#1: ActionValuesDescribed expects a multiple of 2 arguments (got 1)",
+		"_root__exact#2: positional completion exceeds accepted arguments: accepts 1 arg(s), received 2",
+		"_root__exact#3: positional completion exceeds accepted arguments: accepts 1 arg(s), received 3",
+		"_root__hidden: completion registered for hidden command",
+		"_root__missing: command was not passed to Gen",
+		"_root__optional: command was not passed to Gen",
+		"_root__valid#1: ActionStyledValues expects a multiple of 2 arguments (got 1)",
+	}
+	if actual := s.check(); strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected:\n%v\n\nactual:\n%v", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}